@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"devplaza/logger"
+	"devplaza/models"
+	"devplaza/utils"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 个人访问令牌不能再用来管理令牌
+func rejectApiToken(c *gin.Context) bool {
+	if _, ok := c.Get("api_token_id"); ok {
+		utils.ErrorResponse(c, http.StatusForbidden, "API tokens cannot manage tokens", nil)
+		return true
+	}
+	return false
+}
+
+func ListApiTokens(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+	userId, _ := uid.(uint)
+
+	tokens, err := models.ListUserApiTokens(userId)
+	if err != nil {
+		logger.Log.Errorf("list api tokens failed: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "internal error", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "success", tokens)
+}
+
+func CreateApiToken(c *gin.Context) {
+	if rejectApiToken(c) {
+		return
+	}
+
+	var req CreateApiTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request", nil)
+		return
+	}
+
+	uid, ok := c.Get("uid")
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+	userId, _ := uid.(uint)
+
+	// 令牌范围不能超过用户当前拥有的权限
+	perms, _ := c.Get("permissions")
+	permSet := utils.ToSet(perms.([]string))
+	for _, scope := range req.Scopes {
+		if _, ok := permSet[scope]; !ok {
+			utils.ErrorResponse(c, http.StatusBadRequest, "invalid scope: "+scope, nil)
+			return
+		}
+	}
+
+	secret, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "generate token error", nil)
+		return
+	}
+	plain := utils.ApiTokenPrefix + secret
+
+	token := models.ApiToken{
+		UserId:    userId,
+		Name:      req.Name,
+		Prefix:    plain[:len(utils.ApiTokenPrefix)+8],
+		TokenHash: utils.HashToken(plain),
+		Scopes:    req.Scopes,
+		// 继承创建时会话的两步验证状态
+		MfaVerified: c.GetBool("mfa"),
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := token.Create(); err != nil {
+		logger.Log.Errorf("create api token failed: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "create fail", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "create success", CreateApiTokenResponse{ApiToken: token, Token: plain})
+}
+
+func RevokeApiToken(c *gin.Context) {
+	if rejectApiToken(c) {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID", nil)
+		return
+	}
+
+	uid, ok := c.Get("uid")
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+	userId, _ := uid.(uint)
+
+	if err := models.RevokeApiToken(userId, uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "token not found", nil)
+			return
+		}
+		logger.Log.Errorf("revoke api token failed: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "revoke fail", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "revoke success", nil)
+}
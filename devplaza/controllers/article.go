@@ -2,10 +2,11 @@ package controllers
 
 import (
 	"devplaza/models"
+	"devplaza/policy"
 	"devplaza/utils"
+	"devplaza/workflow"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -29,6 +30,7 @@ func CreateArticle(c *gin.Context) {
 		SourceType:  req.SourceType,
 		Author:      req.Author,
 		Translator:  req.Translator,
+		Language:    req.Language,
 	}
 
 	uid, ok := c.Get("uid")
@@ -39,8 +41,8 @@ func CreateArticle(c *gin.Context) {
 
 	userId, _ := uid.(uint)
 	article.PublisherId = uint(userId)
-	// 创建数据库记录
-	if err := article.Create(); err != nil {
+	// 创建数据库记录，同时记录第一个版本
+	if err := article.SaveWithRevision(userId); err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
 		return
 	}
@@ -63,6 +65,7 @@ func GetArticle(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid Article", nil)
 		return
 	}
+	article.TranslationOutdated = models.TranslationOutdated(policy.Blog, article.ID, article.TranslationGroupId, article.TranslationSourceVersion)
 
 	utils.SuccessResponse(c, http.StatusOK, "success", article)
 }
@@ -75,6 +78,7 @@ func QueryArticles(c *gin.Context) {
 	order := c.DefaultQuery("order", "desc")
 	publishStatus, _ := strconv.Atoi(c.DefaultQuery("publish_status", "0"))
 	userId, _ := strconv.Atoi(c.Query("user_id"))
+	language := c.Query("language")
 
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "6"))
@@ -86,6 +90,7 @@ func QueryArticles(c *gin.Context) {
 		Category:      category,
 		PublishStatus: publishStatus,
 		PublisherId:   userId,
+		Language:      language,
 		OrderDesc:     order == "desc",
 		Page:          page,
 		PageSize:      pageSize,
@@ -121,20 +126,12 @@ func DeleteArticle(c *gin.Context) {
 	var article models.Article
 	article.ID = uint(id)
 
-	if err = article.GetByID(uint(id)); err != nil {
+	if err = article.Load(uint(id)); err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid article", nil)
 		return
 	}
 
-	uid, ok := c.Get("uid")
-	if !ok {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
-		return
-	}
-
-	userId, _ := uid.(uint)
-	if article.PublisherId != userId {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "not author", nil)
+	if !authorize(c, policy.Delete, articleResource(&article)) {
 		return
 	}
 
@@ -142,6 +139,7 @@ func DeleteArticle(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete article", nil)
 		return
 	}
+	recordAudit(c, AuditDelete, policy.Blog, article.ID, article, nil)
 	utils.SuccessResponse(c, http.StatusOK, "delete success", nil)
 }
 
@@ -162,20 +160,12 @@ func UpdateArticle(c *gin.Context) {
 	var article models.Article
 	article.ID = uint(id)
 
-	if err = article.GetByID(uint(id)); err != nil {
+	if err = article.Load(uint(id)); err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid article", nil)
 		return
 	}
 
-	uid, ok := c.Get("uid")
-	if !ok {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
-		return
-	}
-
-	userId, _ := uid.(uint)
-	if article.PublisherId != userId {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "not author", nil)
+	if !authorize(c, policy.Update, articleResource(&article)) {
 		return
 	}
 
@@ -187,13 +177,23 @@ func UpdateArticle(c *gin.Context) {
 	article.CoverImg = req.CoverImg
 	article.Tags = req.Tags
 	article.Author = req.Author
+	if req.Language != "" && req.Language != article.Language {
+		if !translationLanguageAvailable(c, policy.Blog, article.ID, article.TranslationGroupId, req.Language) {
+			return
+		}
+		article.Language = req.Language
+	}
 
-	article.PublishStatus = 1 // 更新后需要重新审核
+	from := article.PublishStatus
+	article.PublishStatus = uint(workflow.AfterEdit(workflow.Status(from))) // 已发布、被驳回的内容更新后重新进入审核
 
-	if err := article.Update(); err != nil {
+	uid, _ := c.Get("uid")
+	userId, _ := uid.(uint)
+	if err := article.SaveWithRevision(userId); err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update article", nil)
 		return
 	}
+	recordEditTransition(c, policy.Blog, article.ID, from, article.PublishStatus)
 	utils.SuccessResponse(c, http.StatusOK, "success", article)
 }
 
@@ -214,23 +214,85 @@ func UpdateArticlePublishStatus(c *gin.Context) {
 	var article models.Article
 	article.ID = uint(id)
 
-	if err = article.GetByID(uint(id)); err != nil {
+	if err = article.Load(uint(id)); err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid artcile", nil)
 		return
 	}
 
-	if article.PublishStatus != 1 && article.PublishStatus != 2 {
-		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid status", nil)
+	// 作者和审核员可执行的状态变更不同，由状态机和策略共同决定
+	publishAt, ok := requestPublishAt(c, req.PublishAt, article.PublishAt)
+	if !ok {
+		return
+	}
+	if !changePublishStatus(c, &article, policy.Blog, article.ID, articleResource(&article), article.PublishStatus, req.PublishStatus, req.Comment, publishAt) {
 		return
 	}
 
-	// TODO: 2 -> 1 ?
-	now := time.Now()
-	article.PublishStatus = req.PublishStatus
-	article.PublishTime = &now
+	// 重新读取变更后的状态和发布时间
+	if err := article.Load(article.ID); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get article", nil)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "success", article)
+}
 
-	if err := article.Update(); err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update article", nil)
+func GetArticleTransitions(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID", nil)
+		return
+	}
+
+	var article models.Article
+	article.ID = uint(id)
+
+	if err = article.Load(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid article", nil)
+		return
+	}
+
+	listPublishTransitions(c, policy.Blog, article.ID, articleResource(&article), article.PublishStatus)
+}
+
+// ScheduleArticle 设置定时发布时间
+func ScheduleArticle(c *gin.Context) {
+	article, ok := loadArticle(c)
+	if !ok {
+		return
+	}
+
+	var req ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid input data", nil)
+		return
+	}
+	publishAt, err := utils.ParseTime(req.PublishAt)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid publish_at", nil)
+		return
+	}
+
+	if !scheduleContent(c, article, policy.Blog, article.ID, articleResource(article), article.PublishStatus, publishAt) {
+		return
+	}
+	article.PublishAt = &publishAt
+	utils.SuccessResponse(c, http.StatusOK, "success", article)
+}
+
+// CancelArticleSchedule 取消定时发布
+func CancelArticleSchedule(c *gin.Context) {
+	article, ok := loadArticle(c)
+	if !ok {
+		return
+	}
+
+	if !cancelSchedule(c, article, policy.Blog, article.ID, articleResource(article), article.PublishStatus, article.PublishAt) {
+		return
+	}
+
+	if err := article.Load(article.ID); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get article", nil)
 		return
 	}
 	utils.SuccessResponse(c, http.StatusOK, "success", article)
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"devplaza/logger"
+	"devplaza/models"
+	"devplaza/utils"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 审计动作
+const (
+	AuditDelete          = "delete"
+	AuditUpdate          = "update"
+	AuditCreate          = "create"
+	AuditPublishStatus   = "publish_status"
+	AuditAttach          = "attach"
+	AuditDetach          = "detach"
+	AuditSetRoles        = "set_roles"
+	AuditRestore         = "restore"
+	AuditSchedule        = "schedule"
+	AuditAssign          = "assign"
+	AuditUnassign        = "unassign"
+	AuditSetContributors = "set_contributors"
+)
+
+// 单次导出的最大行数
+const auditExportLimit = 10000
+
+// recordAudit 记录一次敏感操作；before/after 为操作前后的资源快照，可为 nil。
+// 写入失败只记日志，不影响请求本身
+func recordAudit(c *gin.Context, action, resourceType string, resourceId uint, before, after interface{}) {
+	entry := models.AuditLog{
+		ActorId:      c.GetUint("uid"),
+		Action:       resourceType + "." + action,
+		ResourceType: resourceType,
+		ResourceId:   resourceId,
+		Before:       auditSnapshot(before),
+		After:        auditSnapshot(after),
+		Ip:           c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+		Method:       c.Request.Method,
+		Path:         c.Request.URL.Path,
+	}
+	if id, ok := c.Get("api_token_id"); ok {
+		tokenId, _ := id.(uint)
+		entry.ApiTokenId = &tokenId
+	}
+
+	if err := entry.Create(); err != nil {
+		logger.Log.Errorf("record audit log %s %s#%d failed: %v", entry.Action, resourceType, resourceId, err)
+	}
+}
+
+func auditSnapshot(v interface{}) models.JSONB {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		logger.Log.Errorf("marshal audit snapshot failed: %v", err)
+		return nil
+	}
+	return data
+}
+
+func auditFilter(c *gin.Context) models.AuditLogFilter {
+	actorId, _ := strconv.Atoi(c.Query("actor_id"))
+	resourceId, _ := strconv.Atoi(c.Query("resource_id"))
+	page, pageSize := pageParams(c)
+
+	filter := models.AuditLogFilter{
+		ActorId:      uint(actorId),
+		Action:       c.Query("action"),
+		ResourceType: c.Query("resource_type"),
+		ResourceId:   uint(resourceId),
+		Page:         page,
+		PageSize:     pageSize,
+	}
+	if from, err := utils.ParseTime(c.Query("from")); err == nil {
+		filter.From = &from
+	}
+	if to, err := utils.ParseTime(c.Query("to")); err == nil {
+		filter.To = &to
+	}
+	return filter
+}
+
+func ListAuditLogs(c *gin.Context) {
+	filter := auditFilter(c)
+
+	logs, total, err := models.QueryAuditLogs(filter)
+	if err != nil {
+		logger.Log.Errorf("query audit logs failed: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "query fail", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "query success", QueryAuditLogsResponse{
+		AuditLogs: logs,
+		Page:      filter.Page,
+		PageSize:  filter.PageSize,
+		Total:     total,
+	})
+}
+
+// 按相同的筛选条件导出 CSV，最多 auditExportLimit 行
+func ExportAuditLogs(c *gin.Context) {
+	filter := auditFilter(c)
+	filter.Page = 1
+	filter.PageSize = auditExportLimit
+
+	logs, _, err := models.QueryAuditLogs(filter)
+	if err != nil {
+		logger.Log.Errorf("export audit logs failed: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "export fail", nil)
+		return
+	}
+
+	filename := fmt.Sprintf("audit-logs-%s.csv", time.Now().Format("20060102150405"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{"id", "created_at", "actor_id", "api_token_id", "action", "resource_type", "resource_id", "before", "after", "ip", "user_agent", "method", "path"})
+	for _, l := range logs {
+		tokenId := ""
+		if l.ApiTokenId != nil {
+			tokenId = strconv.FormatUint(uint64(*l.ApiTokenId), 10)
+		}
+		_ = w.Write([]string{
+			strconv.FormatUint(uint64(l.ID), 10),
+			l.CreatedAt.Format(time.RFC3339),
+			strconv.FormatUint(uint64(l.ActorId), 10),
+			tokenId,
+			csvSafe(l.Action),
+			csvSafe(l.ResourceType),
+			strconv.FormatUint(uint64(l.ResourceId), 10),
+			csvSafe(string(l.Before)),
+			csvSafe(string(l.After)),
+			csvSafe(l.Ip),
+			csvSafe(l.UserAgent),
+			l.Method,
+			csvSafe(l.Path),
+		})
+	}
+	w.Flush()
+}
+
+// csvSafe 防止以公式字符开头的值在表格软件中被当作公式执行
+func csvSafe(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
@@ -1,15 +1,19 @@
 package controllers
 
 import (
+	"devplaza/identity"
 	"devplaza/logger"
 	"devplaza/models"
 	"devplaza/utils"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/spf13/viper"
+	"gorm.io/gorm"
 )
 
 func HandleLogin(c *gin.Context) {
@@ -20,112 +24,144 @@ func HandleLogin(c *gin.Context) {
 		return
 	}
 
-	var accessRequest AccessTokenRequest
-	accessRequest.ClientId = viper.GetString("oauth.clientId")
-	accessRequest.ClientSecret = viper.GetString("oauth.clientSecret")
-	accessRequest.Code = req.Code
-
-	var reqArgs utils.HTTPRequestParams
-	reqArgs.URL = viper.GetString("oauth.accessApi")
-	reqArgs.Method = "POST"
-	reqArgs.Body = accessRequest
-
-	var result string
-	var err error
-	result, err = utils.SendHTTPRequest(reqArgs)
+	provider, err := identity.Get(req.Provider)
 	if err != nil {
-		logger.Log.Errorf("ServerError: %v", err)
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Network error, please try again later.", nil)
+		utils.ErrorResponse(c, http.StatusBadRequest, "Unsupported login provider", nil)
 		return
 	}
 
-	var tokenResp AccessTokenResponse
-	err = json.Unmarshal([]byte(result), &tokenResp)
+	ident, err := provider.Exchange(c.Request.Context(), req.Code, req.RedirectUri)
 	if err != nil {
 		logger.Log.Errorf("ServerError: %v", err)
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Network error, please try again later.", nil)
 		return
 	}
 
-	if tokenResp.Status != 200 {
-		logger.Log.Errorf("ServerError: %v", tokenResp)
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Network error, please try again later.", nil)
-		return
-	}
-
-	accessToken := tokenResp.Data.Token
-	reqArgs.URL = viper.GetString("oauth.getUser")
-	reqArgs.Method = "GET"
-
-	header := make(map[string]string)
-	header["Authorization"] = fmt.Sprintf("Bearer %s", accessToken)
-	reqArgs.Headers = header
-
-	userResult, err := utils.SendHTTPRequest(reqArgs)
+	user, err := resolveIdentityUser(ident)
 	if err != nil {
-		logger.Log.Errorf("SendHTTPRequest err: %v", err)
+		logger.Log.Errorf("ServerError: %v", err)
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Network error, please try again later.", nil)
 		return
 	}
 
-	var resp GetUserResponse
-	err = json.Unmarshal([]byte(userResult), &resp)
+	perms, err := models.GetUserWithPermissions(user.ID)
 	if err != nil {
-		logger.Log.Errorf("Unmarshal err: %v", err)
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Network error, please try again later.", nil)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "get permissions error", nil)
 		return
 	}
 
-	if resp.Status != 200 {
-		logger.Log.Errorf("ServerError: %v", resp)
-		utils.ErrorResponse(c, http.StatusInternalServerError, resp.Message, nil)
-		return
+	completeLogin(c, user, perms)
+}
+
+// resolveIdentityUser 通过关联身份找到用户；未关联时按双方都已验证的邮箱匹配已有用户，仍找不到则新建，并记录关联
+func resolveIdentityUser(ident *identity.Identity) (*models.User, error) {
+	if link, err := models.GetIdentity(ident.Provider, ident.Subject); err == nil {
+		user, err := models.GetUserById(link.UserId)
+		if err != nil {
+			return nil, err
+		}
+		// 原有 OAuth 服务的资料保持同步
+		if ident.Provider == identity.DefaultProvider {
+			// user.Avatar = ident.Avatar
+			// user.Username = ident.Username
+			user.Github = ident.Github
+			if err := syncIdentityEmail(user, ident); err != nil {
+				return nil, err
+			}
+			return user, models.UpdateUser(user)
+		}
+		return user, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
 	}
 
 	var user *models.User
+	emailTaken := false
+	if ident.Email != "" && ident.EmailVerified {
+		u := models.User{Email: ident.Email}
+		if err := models.GetUserByEmail(&u); err == nil {
+			// 只关联邮箱也已验证的账号，否则任何人都能先注册他人邮箱再等对方登录
+			verified, err := u.HasVerifiedEmail()
+			if err != nil {
+				return nil, err
+			}
+			if verified {
+				user = &u
+			}
+			emailTaken = true
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
 
-	userId := resp.Data.Uid
-	user, err = models.GetUserByUid(userId)
-	if err == nil {
-		user.Uid = resp.Data.Uid
-		// user.Avatar = resp.Data.Avatar
-		// user.Username = resp.Data.UserName
-		user.Email = resp.Data.Email
-		user.Github = resp.Data.Github
-		err = models.UpdateUser(user)
-	} else {
-		var u models.User
-		u.Uid = resp.Data.Uid
-		u.Avatar = resp.Data.Avatar
-		u.Email = resp.Data.Email
-		u.Username = resp.Data.UserName
-		u.Github = resp.Data.Github
+	if user == nil {
+		u := models.User{
+			Avatar:   ident.Avatar,
+			Email:    ident.Email,
+			Username: ident.Username,
+			Github:   ident.Github,
+			Address:  ident.Address,
+		}
+		if ident.Provider == identity.DefaultProvider {
+			uid, _ := strconv.ParseUint(ident.Subject, 10, 64)
+			u.Uid = uint(uid)
+		}
+		// 没有已验证邮箱或邮箱已被未验证的账号占用时使用占位邮箱，之后可以在已有账号中主动关联
+		if u.Email == "" || !ident.EmailVerified || emailTaken {
+			u.Email = placeholderEmail(ident)
+		} else {
+			now := time.Now()
+			u.EmailVerifiedAt = &now
+		}
+		if err := models.CreateUser(&u); err != nil {
+			return nil, err
+		}
 		user = &u
-		err = models.CreateUser(user)
 	}
 
-	if err != nil {
-		logger.Log.Errorf("ServerError: %v", err)
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Network error, please try again later.", nil)
-		return
+	if err := linkIdentity(user.ID, ident); err != nil {
+		return nil, err
 	}
+	return user, nil
+}
 
-	// TODO: gocache?
-	perms, err := models.GetUserWithPermissions(user.ID)
-	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "get permissions error", nil)
-		return
+// syncIdentityEmail 使用提供方验证过的邮箱更新用户邮箱；未验证或已被其他账号使用时保持原邮箱
+func syncIdentityEmail(user *models.User, ident *identity.Identity) error {
+	email := strings.ToLower(strings.TrimSpace(ident.Email))
+	if email == "" || !ident.EmailVerified || email == strings.ToLower(user.Email) {
+		return nil
+	}
+	existing := models.User{Email: email}
+	if err := models.GetUserByEmail(&existing); err == nil {
+		return nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
 	}
 
-	var loginResp LoginResponse
-	loginResp.User = *user
-	loginResp.Permissions = perms
+	if err := models.ChangeUserEmail(user, email); err != nil {
+		return err
+	}
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	return models.MarkIdentityEmailVerified(passwordProvider, email)
+}
 
-	token, err := utils.GenerateToken(user.ID, user.Email, user.Avatar, user.Username, user.Github, perms)
-	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "generate token error", nil)
-		return
+// linkIdentity 记录用户与身份的关联
+func linkIdentity(userId uint, ident *identity.Identity) error {
+	link := models.UserIdentity{
+		UserId:   userId,
+		Provider: ident.Provider,
+		Subject:  ident.Subject,
+		Address:  ident.Address,
+	}
+	if ident.EmailVerified {
+		link.Email = ident.Email
+		link.EmailVerified = true
 	}
-	loginResp.Token = token
-	utils.SuccessResponse(c, http.StatusOK, "success", loginResp)
+	return link.Create()
+}
+
+// placeholderEmail 没有可用邮箱的用户的占位邮箱，所有登录方式统一使用该格式
+func placeholderEmail(ident *identity.Identity) string {
+	return fmt.Sprintf("%s.%s@users.devplaza", ident.Provider, strings.ToLower(ident.Subject))
 }
@@ -2,9 +2,14 @@ package controllers
 
 import (
 	"devplaza/models"
+	"devplaza/utils"
+	"devplaza/workflow"
 	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
 )
 
 // event
@@ -48,7 +53,9 @@ type UpdateEventRequest struct {
 }
 
 type UpdateEventPublishStatusRequest struct {
-	PublishStatus uint `json:"publish_status"`
+	PublishStatus uint   `json:"publish_status" binding:"required"`
+	Comment       string `json:"comment"`    // 驳回、下架时必填
+	PublishAt     string `json:"publish_at"` // 审核通过时指定则定时发布，格式 2006-01-02 15:04:05
 }
 
 // login
@@ -59,56 +66,57 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type RegisterRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
 type LoginResponse struct {
 	models.User
-	Permissions []string `json:"permissions"`
-	Token       string   `json:"token"`
+	Roles        []string `json:"roles"`
+	Permissions  []string `json:"permissions"`
+	Token        string   `json:"token"`
+	RefreshToken string   `json:"refresh_token"`
+	ExpiresIn    int64    `json:"expires_in"` // 访问令牌有效期（秒）
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
 // OAUTH
 type SignRequest struct {
-	Code string `json:"code" binding:"required"`
+	Code        string `json:"code" binding:"required"`
+	Provider    string `json:"provider"` // 身份提供方，默认 oauth
+	RedirectUri string `json:"redirect_uri"`
 }
 
 type SignResponse struct {
 	Token string `json:"token"`
 }
 
-type AccessTokenRequest struct {
-	ClientId     string `json:"client_id"`
-	ClientSecret string `json:"client_secret"`
-	Code         string `json:"code"`
+// SIWE
+type SiweNonceResponse struct {
+	Nonce string `json:"nonce"`
 }
 
-// 定义响应的结构体
-type AccessTokenResponse struct {
-	Status int `json:"status"`
-	Code   int `json:"code"`
-	Data   struct {
-		Token string `json:"token"`
-	} `json:"data"`
-	Time    int64  `json:"time"`
-	Message string `json:"message"`
-	ID      string `json:"id"`
-}
-
-// 定义数据部分的结构体
-type UserData struct {
-	Uid      uint   `json:"uid"`
-	Avatar   string `json:"avatar"`
-	UserName string `json:"user_name"`
-	Email    string `json:"email"`
-	Github   string `json:"github"`
-}
-
-// 定义顶层响应的结构体
-type GetUserResponse struct {
-	ID      string   `json:"id"`
-	Status  int      `json:"status"`
-	Code    int      `json:"code"`
-	Data    UserData `json:"data"`
-	Time    int64    `json:"time"`
-	Message string   `json:"message"`
+type SiweLoginRequest struct {
+	Message   string `json:"message" binding:"required"`
+	Signature string `json:"signature" binding:"required"`
 }
 
 // article
@@ -123,6 +131,7 @@ type CreateArticleRequest struct {
 	Tags       []string `json:"tags"`
 	Author     string   `json:"author" binding:"required"`
 	Translator string   `json:"translator"`
+	Language   string   `json:"language" binding:"omitempty,oneof=zh en"` // 默认为 zh
 }
 
 type QueryArticlesResponse struct {
@@ -150,10 +159,13 @@ type UpdateArticleRequest struct {
 	Tags       []string `json:"tags"`
 	Author     string   `json:"author" binding:"required"`
 	Translator string   `json:"translator"`
+	Language   string   `json:"language" binding:"omitempty,oneof=zh en"` // 默认为 zh
 }
 
 type UpdateBlogPublishStatusRequest struct {
-	PublishStatus uint `json:"publish_status"`
+	PublishStatus uint   `json:"publish_status" binding:"required"`
+	Comment       string `json:"comment"`    // 驳回、下架时必填
+	PublishAt     string `json:"publish_at"` // 审核通过时指定则定时发布，格式 2006-01-02 15:04:05
 }
 
 // statistic
@@ -186,6 +198,22 @@ type CreateDappRequest struct {
 	Tags        []string `json:"tags"`
 }
 
+type UpdateDappRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description" binding:"required"`
+	X           string   `json:"x" binding:"required"`
+	Logo        string   `json:"logo" binding:"required"`
+	Site        string   `json:"site" binding:"required"`
+	CoverImg    string   `json:"cover_img" binding:"required"`
+	CategoryId  uint     `json:"category_id" binding:"required"`
+	Tags        []string `json:"tags"`
+}
+
+type ReviewDappRequest struct {
+	PublishStatus uint   `json:"publish_status" binding:"required"` // 2:通过 8:拒绝
+	Comment       string `json:"comment"`                           // 拒绝时必填
+}
+
 type QueryDappsResponse struct {
 	Dapps    []models.Dapp `json:"dapps"`
 	Page     int           `json:"page"`
@@ -211,6 +239,7 @@ type CreateTutorialRequest struct {
 	SourceLink string   `json:"source_link"`
 	CoverImg   string   `json:"cover_img" binding:"required"`
 	Tags       []string `json:"tags"`
+	Language   string   `json:"language" binding:"omitempty,oneof=zh en"` // 默认为 zh
 }
 
 type QueryTutorialsResponse struct {
@@ -229,10 +258,13 @@ type UpdateTutorialRequest struct {
 	SourceLink string   `json:"source_link"`
 	CoverImg   string   `json:"cover_img" binding:"required"`
 	Tags       []string `json:"tags"`
+	Language   string   `json:"language" binding:"omitempty,oneof=zh en"` // 默认为 zh
 }
 
 type UpdateTutorialPublishStatusRequest struct {
-	PublishStatus uint `json:"publish_status" binding:"required"`
+	PublishStatus uint   `json:"publish_status" binding:"required"`
+	Comment       string `json:"comment"`    // 驳回、下架时必填
+	PublishAt     string `json:"publish_at"` // 审核通过时指定则定时发布，格式 2006-01-02 15:04:05
 }
 
 // feedback
@@ -294,6 +326,204 @@ type UpdateUserRequest struct {
 	Github   string `json:"github"`
 }
 
+type LinkIdentityRequest struct {
+	Provider    string `json:"provider" binding:"required"`
+	Code        string `json:"code" binding:"required"`
+	RedirectUri string `json:"redirect_uri"`
+}
+
+type CreateApiTokenRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days"` // 0 表示永不过期
+}
+
+type CreateApiTokenResponse struct {
+	models.ApiToken
+	Token string `json:"token"` // 明文令牌只返回一次
+}
+
+// rbac
+type PermissionRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+type RoleRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+type PermissionGroupRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+type AttachPermissionsRequest struct {
+	PermissionIds []uint `json:"permission_ids" binding:"required,min=1"`
+}
+
+type AttachGroupsRequest struct {
+	GroupIds []uint `json:"group_ids" binding:"required,min=1"`
+}
+
+type SetUserRolesRequest struct {
+	RoleIds []uint `json:"role_ids"` // 为空表示移除所有角色
+}
+
+type AddUserRolesRequest struct {
+	RoleIds []uint `json:"role_ids" binding:"required,min=1"`
+}
+
+type QueryRoleUsersResponse struct {
+	Users    []models.User `json:"users"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"page_size"`
+	Total    int64         `json:"total"`
+}
+
+type CreateModeratorRequest struct {
+	UserId     uint   `json:"user_id" binding:"required"`
+	Permission string `json:"permission" binding:"required"`
+	ScopeType  string `json:"scope_type" binding:"required,oneof=category dapp"`
+	ScopeId    uint   `json:"scope_id" binding:"required"`
+}
+
+type QueryModeratorsResponse struct {
+	Moderators []models.ModeratorAssignment `json:"moderators"`
+	Page       int                          `json:"page"`
+	PageSize   int                          `json:"page_size"`
+	Total      int64                        `json:"total"`
+}
+
+type QueryAuditLogsResponse struct {
+	AuditLogs []models.AuditLog `json:"audit_logs"`
+	Page      int               `json:"page"`
+	PageSize  int               `json:"page_size"`
+	Total     int64             `json:"total"`
+}
+
+type ScheduleRequest struct {
+	PublishAt string `json:"publish_at" binding:"required"` // 格式 2006-01-02 15:04:05
+}
+
+type PublishTransitionsResponse struct {
+	PublishStatus uint                       `json:"publish_status"`
+	Next          []workflow.Status          `json:"next"` // 当前用户可变更到的状态
+	Transitions   []models.PublishTransition `json:"transitions"`
+}
+
+type RevisionDiffResponse struct {
+	From  *models.Revision `json:"from"` // 不含正文，version 为 0 表示与空内容比较
+	To    *models.Revision `json:"to"`
+	Lines []utils.DiffLine `json:"lines"`
+}
+
+type QueryReviewQueueResponse struct {
+	Items    []models.ReviewQueueItem `json:"items"`
+	Page     int                      `json:"page"`
+	PageSize int                      `json:"page_size"`
+	Total    int64                    `json:"total"`
+}
+
+type AssignReviewRequest struct {
+	ReviewerId uint `json:"reviewer_id" binding:"required"`
+}
+
+type CreateReviewCommentRequest struct {
+	Body     string `json:"body" binding:"required"`
+	ParentId *uint  `json:"parent_id"`
+	Anchor   string `json:"anchor"`  // 行内评论的位置，如 L12 或引用的原文
+	Version  int    `json:"version"` // 评论针对的修订版本，默认为最新版本
+}
+
+type ContributorRequest struct {
+	UserId uint   `json:"user_id" binding:"required"`
+	Role   string `json:"role" binding:"required,oneof=author translator editor"`
+}
+
+type SetContributorsRequest struct {
+	Contributors []ContributorRequest `json:"contributors" binding:"dive"`
+}
+
+type AttachTranslationRequest struct {
+	SourceId uint `json:"source_id" binding:"required"` // 原文 ID
+}
+
+type CreateSeriesRequest struct {
+	Title    string `json:"title" binding:"required"`
+	Desc     string `json:"desc"`
+	CoverImg string `json:"cover_img"`
+}
+
+type UpdateSeriesRequest struct {
+	Title    string `json:"title" binding:"required"`
+	Desc     string `json:"desc"`
+	CoverImg string `json:"cover_img"`
+}
+
+type QuerySeriesResponse struct {
+	Series   []models.Series `json:"series"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"page_size"`
+	Total    int64           `json:"total"`
+}
+
+type AddSeriesTutorialRequest struct {
+	TutorialId uint `json:"tutorial_id" binding:"required"`
+}
+
+type ReorderSeriesRequest struct {
+	TutorialIds []uint `json:"tutorial_ids" binding:"required"` // 系列中全部教程的新顺序
+}
+
+type GetUserResponse struct {
+	*models.User
+	Contributions []models.Contribution `json:"contributions"` // 作为共同作者、译者、编辑参与的已发布内容
+}
+
+type MfaChallengeResponse struct {
+	MfaRequired bool   `json:"mfa_required"`
+	MfaToken    string `json:"mfa_token"`
+}
+
+type MfaLoginRequest struct {
+	MfaToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"` // 验证码或恢复码
+}
+
+type TotpCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TotpSetupResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthUrl string `json:"otpauth_url"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"` // 明文恢复码只返回一次
+}
+
 type FollowStatesRequest struct {
 	UserIDs []uint `json:"user_ids" binding:"required"`
 }
+
+// maxPageSize 分页接口每页最多返回的条数
+const maxPageSize = 100
+
+// pageParams 读取 page、page_size 参数，默认每页 20 条，最多 maxPageSize 条
+func pageParams(c *gin.Context) (page, pageSize int) {
+	page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ = strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	return page, pageSize
+}
@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"devplaza/logger"
+	"devplaza/mailer"
+	"devplaza/models"
+	"devplaza/policy"
+	"devplaza/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// setContributors 替换内容的贡献者。只有发布者本人和审核员可以修改，贡献者不能再添加其他贡献者。
+// 新添加的贡献者会收到邀请，接受后才能修改内容并在个人主页中展示
+func setContributors(c *gin.Context, contentType string, id uint, title string, resource policy.Resource) {
+	resource.Contributors = nil
+	if !authorize(c, policy.Update, resource) {
+		return
+	}
+
+	var req SetContributorsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid input data", nil)
+		return
+	}
+
+	seen := make(map[uint]bool)
+	contributors := make([]models.Contributor, 0, len(req.Contributors))
+	for _, r := range req.Contributors {
+		if r.UserId == resource.OwnerId {
+			utils.ErrorResponse(c, http.StatusBadRequest, "the publisher is already the author and can not be a contributor", nil)
+			return
+		}
+		if seen[r.UserId] {
+			utils.ErrorResponse(c, http.StatusBadRequest, "duplicate contributor", nil)
+			return
+		}
+		seen[r.UserId] = true
+		contributors = append(contributors, models.Contributor{UserId: r.UserId, Role: r.Role})
+	}
+
+	before, err := models.ListContributors(contentType, id)
+	if err != nil {
+		logger.Log.Errorf("list %s %d contributors failed: %v", contentType, id, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to list contributors", nil)
+		return
+	}
+
+	invited, err := models.SetContributors(contentType, id, contributors)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidContributor) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "user not found", nil)
+			return
+		}
+		logger.Log.Errorf("set %s %d contributors failed: %v", contentType, id, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to set contributors", nil)
+		return
+	}
+	if len(invited) > 0 {
+		// 邮件在后台发送，邮件服务慢或不可用时不影响已保存的修改
+		go notifyContributorInvitations(invited, title)
+	}
+
+	after, err := models.ListContributors(contentType, id)
+	if err != nil {
+		logger.Log.Errorf("list %s %d contributors failed: %v", contentType, id, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to list contributors", nil)
+		return
+	}
+	recordAudit(c, AuditSetContributors, contentType, id, before, after)
+	utils.SuccessResponse(c, http.StatusOK, "success", after)
+}
+
+// notifyContributorInvitations 邮件通知被邀请的贡献者，失败只记日志
+func notifyContributorInvitations(invited []models.Contributor, title string) {
+	for _, contributor := range invited {
+		user, err := models.GetUserById(contributor.UserId)
+		if err != nil {
+			logger.Log.Errorf("get contributor %d failed: %v", contributor.UserId, err)
+			continue
+		}
+		body := fmt.Sprintf("Hi %s,\n\nYou have been invited to join \"%s\" as %s on DevPlaza. "+
+			"You can accept or decline the invitation from your contributions page.\n", user.Username, title, contributor.Role)
+		if err := mailer.Default().Send(user.Email, "DevPlaza contributor invitation", body); err != nil {
+			logger.Log.Errorf("send contributor invitation to %d failed: %v", contributor.UserId, err)
+		}
+	}
+}
+
+func SetArticleContributors(c *gin.Context) {
+	article, ok := loadArticle(c)
+	if !ok {
+		return
+	}
+	setContributors(c, policy.Blog, article.ID, article.Title, articleResource(article))
+}
+
+func SetTutorialContributors(c *gin.Context) {
+	tutorial, ok := loadTutorial(c)
+	if !ok {
+		return
+	}
+	setContributors(c, policy.Tutorial, tutorial.ID, tutorial.Title, tutorialResource(tutorial))
+}
+
+// ListMyInvitations 当前用户尚未接受的贡献者邀请
+func ListMyInvitations(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+	userId, _ := uid.(uint)
+
+	invitations, err := models.ListContributorInvitations(userId)
+	if err != nil {
+		logger.Log.Errorf("list contributor invitations failed: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "internal error", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "success", invitations)
+}
+
+// AcceptInvitation 接受贡献者邀请
+func AcceptInvitation(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID", nil)
+		return
+	}
+
+	uid, ok := c.Get("uid")
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+	userId, _ := uid.(uint)
+
+	contributor, err := models.AcceptContributorInvitation(userId, uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvitationAccepted):
+			utils.ErrorResponse(c, http.StatusConflict, "invitation already accepted", nil)
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.ErrorResponse(c, http.StatusBadRequest, "invitation not found", nil)
+		default:
+			logger.Log.Errorf("accept contributor invitation failed: %v", err)
+			utils.ErrorResponse(c, http.StatusInternalServerError, "accept fail", nil)
+		}
+		return
+	}
+
+	recordAudit(c, AuditUpdate, "contributor", contributor.ID, nil, gin.H{"accepted": true})
+	utils.SuccessResponse(c, http.StatusOK, "accept success", nil)
+}
+
+// DeclineInvitation 拒绝贡献者邀请，已接受时为退出该内容
+func DeclineInvitation(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID", nil)
+		return
+	}
+
+	uid, ok := c.Get("uid")
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+	userId, _ := uid.(uint)
+
+	contributor, err := models.RemoveContributor(userId, uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "invitation not found", nil)
+			return
+		}
+		logger.Log.Errorf("decline contributor invitation failed: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "decline fail", nil)
+		return
+	}
+
+	recordAudit(c, AuditDelete, "contributor", contributor.ID, contributor, nil)
+	utils.SuccessResponse(c, http.StatusOK, "decline success", nil)
+}
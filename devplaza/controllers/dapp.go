@@ -1,9 +1,14 @@
 package controllers
 
 import (
+	"devplaza/logger"
 	"devplaza/models"
+	"devplaza/policy"
 	"devplaza/utils"
+	"devplaza/workflow"
+	"errors"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -47,6 +52,11 @@ func CreateDapp(c *gin.Context) {
 	}
 
 	dapp.UserId = userId
+	// 能审核该分类 Dapp 的用户（需通过两步验证）提交的 Dapp 直接收录，其他用户提交的需要审核
+	dapp.PublishStatus = uint(workflow.PendingReview)
+	if subject, ok := currentSubject(c); ok && policy.Can(subject, policy.Review, dappResource(&dapp)) {
+		dapp.PublishStatus = uint(workflow.Published)
+	}
 	// 创建数据库记录
 	if err := dapp.Create(); err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
@@ -72,14 +82,32 @@ func GetDapp(c *gin.Context) {
 		return
 	}
 
+	// 未收录的 Dapp 只有提交者和审核员可见
+	if !canViewDapp(c, &dapp) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid dapp", nil)
+		return
+	}
+
 	utils.SuccessResponse(c, http.StatusOK, "success", dapp)
 }
 
+func canViewDapp(c *gin.Context, dapp *models.Dapp) bool {
+	if workflow.Status(dapp.PublishStatus) == workflow.Published {
+		return true
+	}
+	subject, ok := currentSubject(c)
+	if !ok {
+		return false
+	}
+	return subject.UserId == dapp.UserId || policy.Can(subject, policy.Review, dappResource(dapp))
+}
+
 func QueryDapps(c *gin.Context) {
 	keyword := c.Query("keyword")
 	tag := c.Query("tag")
 	order := c.DefaultQuery("order", "desc")
 	isFeature, _ := strconv.Atoi(c.DefaultQuery("is_feature", "0"))
+	publishStatus, _ := strconv.Atoi(c.DefaultQuery("publish_status", "0"))
 
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "6"))
@@ -96,11 +124,19 @@ func QueryDapps(c *gin.Context) {
 		MainCategories: mainCategories,
 		SubCategories:  subCategories,
 		IsFeature:      uint(isFeature),
+		PublishStatus:  uint(publishStatus),
 		OrderDesc:      order == "desc",
 		Page:           page,
 		PageSize:       pageSize,
 	}
 
+	// 匿名用户只能看到已收录的 Dapp，登录用户还能看到自己提交的，Dapp 审核员可以看到全部
+	if subject, ok := currentSubject(c); !ok {
+		filter.PublishStatus = uint(workflow.Published)
+	} else if all, _ := policy.ReviewScopes(subject, policy.Dapp); !all {
+		filter.VisibleTo = subject.UserId
+	}
+
 	dapps, total, err := models.QueryDapps(filter)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
@@ -117,6 +153,144 @@ func QueryDapps(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "query success", response)
 }
 
+// UpdateDapp 提交者或审核员修改 Dapp；提交者修改已收录、已拒绝的 Dapp 后需要重新审核
+func UpdateDapp(c *gin.Context) {
+	dapp, ok := loadDapp(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateDappRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", nil)
+		return
+	}
+
+	if !authorize(c, policy.Update, dappResource(dapp)) {
+		return
+	}
+	subject, _ := currentSubject(c)
+	actors := publishActors(subject, dappResource(dapp))
+
+	if req.CategoryId != dapp.CategoryId {
+		var category models.Category
+		if err := category.GetByID(req.CategoryId); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid category id", nil)
+			return
+		}
+
+		// 修改分类时还需要有新分类下的权限，分类审核员不能把 Dapp 移出自己的范围；
+		// 只审核原分类的审核员移动后按提交者处理，需要重新审核
+		moved := *dapp
+		moved.CategoryId = req.CategoryId
+		if !authorize(c, policy.Update, dappResource(&moved)) {
+			return
+		}
+		if !policy.Can(subject, policy.Review, dappResource(&moved)) {
+			actors = slices.DeleteFunc(actors, func(a workflow.Actor) bool { return a == workflow.Reviewer })
+		}
+	}
+
+	// 审核员修改不改变收录状态，提交者修改后按收录流程重新进入审核
+	from := dapp.PublishStatus
+	to := from
+	if !slices.Contains(actors, workflow.Reviewer) {
+		to = uint(workflow.AfterDappEdit(workflow.Status(from)))
+		if to != from {
+			if err := workflow.CheckDapp(workflow.Status(from), workflow.Status(to), "", actors...); err != nil {
+				utils.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+				return
+			}
+		}
+	}
+
+	dapp.Name = req.Name
+	dapp.Description = req.Description
+	dapp.X = req.X
+	dapp.Logo = req.Logo
+	dapp.Site = req.Site
+	dapp.CoverImg = req.CoverImg
+	dapp.CategoryId = req.CategoryId
+	dapp.Tags = req.Tags
+
+	dapp.PublishStatus = to
+	if workflow.Status(to) == workflow.PendingReview {
+		dapp.RejectReason = ""
+	}
+
+	// 不保存预加载的关联，避免覆盖教程或改回原分类
+	dapp.Category = nil
+	dapp.Tutorials = nil
+	if err := dapp.Update(); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update dapp", nil)
+		return
+	}
+	recordEditTransition(c, policy.Dapp, dapp.ID, from, dapp.PublishStatus)
+
+	if err := dapp.GetByID(); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get dapp", nil)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "success", dapp)
+}
+
+// ReviewDapp 审核社区提交的 Dapp：通过、拒绝（必须说明原因）或撤下已收录的 Dapp
+func ReviewDapp(c *gin.Context) {
+	dapp, ok := loadDapp(c)
+	if !ok {
+		return
+	}
+
+	var req ReviewDappRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid input data", nil)
+		return
+	}
+
+	// 全局 Dapp 审核员或该 Dapp 所属分类的审核员
+	if !authorize(c, policy.Review, dappResource(dapp)) {
+		return
+	}
+
+	from := dapp.PublishStatus
+	if err := workflow.CheckDapp(workflow.Status(from), workflow.Status(req.PublishStatus), req.Comment, workflow.Reviewer); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	var reason string
+	if workflow.Status(req.PublishStatus) == workflow.Rejected {
+		reason = req.Comment
+	}
+
+	uid, _ := c.Get("uid")
+	userId, _ := uid.(uint)
+	transition := models.PublishTransition{
+		ContentType: models.ContentDapp,
+		ContentId:   dapp.ID,
+		FromStatus:  from,
+		ToStatus:    req.PublishStatus,
+		ActorId:     userId,
+		Comment:     req.Comment,
+	}
+	if err := models.ChangePublishStatus(dapp, &transition, map[string]interface{}{"reject_reason": reason}); err != nil {
+		if errors.Is(err, models.ErrPublishStatusChanged) {
+			utils.ErrorResponse(c, http.StatusConflict, "status changed, please reload", nil)
+			return
+		}
+		logger.Log.Errorf("review dapp %d failed: %v", dapp.ID, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to review dapp", nil)
+		return
+	}
+	recordAudit(c, AuditPublishStatus, policy.Dapp, dapp.ID,
+		gin.H{"publish_status": from},
+		gin.H{"publish_status": req.PublishStatus, "comment": req.Comment})
+
+	dapp.PublishStatus = req.PublishStatus
+	dapp.RejectReason = reason
+	utils.SuccessResponse(c, http.StatusOK, "success", dapp)
+}
+
 func DeleteDapp(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
@@ -132,10 +306,15 @@ func DeleteDapp(c *gin.Context) {
 		return
 	}
 
+	if !authorize(c, policy.Delete, dappResource(&dapp)) {
+		return
+	}
+
 	if err := dapp.Delete(); err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete dapp", nil)
 		return
 	}
+	recordAudit(c, AuditDelete, policy.Dapp, dapp.ID, dapp, nil)
 	utils.SuccessResponse(c, http.StatusOK, "delete success", nil)
 }
 
@@ -158,3 +337,18 @@ func ParseUintIDs(commaStr string) []uint {
 	}
 	return ids
 }
+
+func loadDapp(c *gin.Context) (*models.Dapp, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID", nil)
+		return nil, false
+	}
+	var dapp models.Dapp
+	dapp.ID = uint(id)
+	if err := dapp.GetByID(); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid dapp", nil)
+		return nil, false
+	}
+	return &dapp, true
+}
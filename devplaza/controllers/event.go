@@ -2,11 +2,12 @@ package controllers
 
 import (
 	"devplaza/models"
+	"devplaza/policy"
 	"devplaza/utils"
+	"devplaza/workflow"
 	"fmt"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -153,10 +154,15 @@ func DeleteEvent(c *gin.Context) {
 		return
 	}
 
+	if !authorize(c, policy.Delete, eventResource(&event)) {
+		return
+	}
+
 	if err := event.Delete(); err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete event", nil)
 		return
 	}
+	recordAudit(c, AuditDelete, policy.Event, event.ID, event, nil)
 	utils.SuccessResponse(c, http.StatusOK, "delete success", nil)
 }
 
@@ -182,6 +188,10 @@ func UpdateEvent(c *gin.Context) {
 		return
 	}
 
+	if !authorize(c, policy.Update, eventResource(&event)) {
+		return
+	}
+
 	startT, _ := utils.ParseTime(req.StartTime)
 	endT, _ := utils.ParseTime(req.EndTime)
 
@@ -206,10 +216,14 @@ func UpdateEvent(c *gin.Context) {
 		event.RegistrationDeadline = &regisDeadline
 	}
 
+	from := event.PublishStatus
+	event.PublishStatus = uint(workflow.AfterEdit(workflow.Status(from))) // 与博客、教程一致，已发布的活动更新后重新进入审核
+
 	if err := event.Update(); err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update event", nil)
 		return
 	}
+	recordEditTransition(c, policy.Event, event.ID, from, event.PublishStatus)
 	utils.SuccessResponse(c, http.StatusOK, "success", event)
 }
 
@@ -235,19 +249,95 @@ func UpdateEventPublishStatus(c *gin.Context) {
 		return
 	}
 
-	if event.PublishStatus != 1 && event.PublishStatus != 2 {
-		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid status", nil)
+	// 作者和审核员可执行的状态变更不同，由状态机和策略共同决定
+	publishAt, ok := requestPublishAt(c, req.PublishAt, event.PublishAt)
+	if !ok {
+		return
+	}
+	if !changePublishStatus(c, &event, policy.Event, event.ID, eventResource(&event), event.PublishStatus, req.PublishStatus, req.Comment, publishAt) {
 		return
 	}
 
-	// TODO: 2 -> 1 ?
-	now := time.Now()
-	event.PublishStatus = req.PublishStatus
-	event.PublishTime = &now
+	// 重新读取变更后的状态和发布时间
+	if err := event.GetByID(event.ID); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get event", nil)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "success", event)
+}
 
-	if err := event.Update(); err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update event", nil)
+func GetEventTransitions(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID", nil)
+		return
+	}
+
+	var event models.Event
+	event.ID = uint(id)
+
+	if err = event.GetByID(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid Event", nil)
+		return
+	}
+
+	listPublishTransitions(c, policy.Event, event.ID, eventResource(&event), event.PublishStatus)
+}
+
+// ScheduleEvent 设置定时发布时间
+func ScheduleEvent(c *gin.Context) {
+	event, ok := loadEvent(c)
+	if !ok {
+		return
+	}
+
+	var req ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid input data", nil)
+		return
+	}
+	publishAt, err := utils.ParseTime(req.PublishAt)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid publish_at", nil)
+		return
+	}
+
+	if !scheduleContent(c, event, policy.Event, event.ID, eventResource(event), event.PublishStatus, publishAt) {
+		return
+	}
+	event.PublishAt = &publishAt
+	utils.SuccessResponse(c, http.StatusOK, "success", event)
+}
+
+// CancelEventSchedule 取消定时发布
+func CancelEventSchedule(c *gin.Context) {
+	event, ok := loadEvent(c)
+	if !ok {
+		return
+	}
+
+	if !cancelSchedule(c, event, policy.Event, event.ID, eventResource(event), event.PublishStatus, event.PublishAt) {
+		return
+	}
+
+	if err := event.GetByID(event.ID); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get event", nil)
 		return
 	}
 	utils.SuccessResponse(c, http.StatusOK, "success", event)
 }
+
+func loadEvent(c *gin.Context) (*models.Event, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID", nil)
+		return nil, false
+	}
+	var event models.Event
+	if err := event.GetByID(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid Event", nil)
+		return nil, false
+	}
+	return &event, true
+}
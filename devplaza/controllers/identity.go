@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"devplaza/identity"
+	"devplaza/logger"
+	"devplaza/models"
+	"devplaza/utils"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 当前用户已关联的登录身份
+func ListMyIdentities(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+	userId, _ := uid.(uint)
+
+	identities, err := models.ListUserIdentities(userId)
+	if err != nil {
+		logger.Log.Errorf("list identities failed: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "internal error", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "success", identities)
+}
+
+// 关联新的 OAuth/OIDC 身份
+func LinkIdentity(c *gin.Context) {
+	var req LinkIdentityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request", nil)
+		return
+	}
+
+	provider, err := identity.Get(req.Provider)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Unsupported login provider", nil)
+		return
+	}
+
+	ident, err := provider.Exchange(c.Request.Context(), req.Code, req.RedirectUri)
+	if err != nil {
+		logger.Log.Errorf("ServerError: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Network error, please try again later.", nil)
+		return
+	}
+
+	linkMyIdentity(c, ident)
+}
+
+// 关联以太坊钱包
+func LinkSiweIdentity(c *gin.Context) {
+	var req SiweLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request", nil)
+		return
+	}
+
+	address, err := verifySiwe(req)
+	if err != nil {
+		logger.Log.Warnf("siwe verify failed: %v", err)
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Authentication failed, please try again.", nil)
+		return
+	}
+
+	linkMyIdentity(c, siweIdentity(address))
+}
+
+func linkMyIdentity(c *gin.Context, ident *identity.Identity) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+	userId, _ := uid.(uint)
+
+	existing, err := models.GetIdentity(ident.Provider, ident.Subject)
+	if err == nil {
+		if existing.UserId == userId {
+			utils.ErrorResponse(c, http.StatusBadRequest, "identity already linked", nil)
+		} else {
+			utils.ErrorResponse(c, http.StatusConflict, "identity is linked to another account", nil)
+		}
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Log.Errorf("get identity failed: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "internal error", nil)
+		return
+	}
+
+	if err := linkIdentity(userId, ident); err != nil {
+		logger.Log.Errorf("link identity failed: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "link fail", nil)
+		return
+	}
+
+	identities, _ := models.ListUserIdentities(userId)
+	utils.SuccessResponse(c, http.StatusOK, "link success", identities)
+}
+
+// 解除身份关联
+func UnlinkIdentity(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID", nil)
+		return
+	}
+
+	uid, ok := c.Get("uid")
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+	userId, _ := uid.(uint)
+	if err := models.DeleteUserIdentity(userId, uint(id)); err != nil {
+		switch {
+		case errors.Is(err, models.ErrLastIdentity):
+			utils.ErrorResponse(c, http.StatusBadRequest, "cannot unlink the last login method", nil)
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.ErrorResponse(c, http.StatusBadRequest, "identity not found", nil)
+		default:
+			logger.Log.Errorf("unlink identity failed: %v", err)
+			utils.ErrorResponse(c, http.StatusInternalServerError, "unlink fail", nil)
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "unlink success", nil)
+}
@@ -0,0 +1,14 @@
+package controllers
+
+import (
+	"devplaza/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS 公开签名公钥（标准 JWK Set 格式，不使用统一响应包装）
+func JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, utils.PublicJWKS())
+}
@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"devplaza/models"
+	"devplaza/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 可以按范围指派的审核权限
+var scopedReviewPermissions = map[string]bool{
+	"blog:review":     true,
+	"tutorial:review": true,
+	"dapp:review":     true,
+}
+
+func ListModerators(c *gin.Context) {
+	userId, _ := strconv.Atoi(c.Query("user_id"))
+	scopeId, _ := strconv.Atoi(c.Query("scope_id"))
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	assignments, total, err := models.QueryModeratorAssignments(models.ModeratorFilter{
+		UserId:    uint(userId),
+		ScopeType: c.Query("scope_type"),
+		ScopeId:   uint(scopeId),
+		Page:      page,
+		PageSize:  pageSize,
+	})
+	if err != nil {
+		rbacError(c, err, "query")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "query success", QueryModeratorsResponse{
+		Moderators: assignments,
+		Page:       page,
+		PageSize:   pageSize,
+		Total:      total,
+	})
+}
+
+func CreateModerator(c *gin.Context) {
+	var req CreateModeratorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request", nil)
+		return
+	}
+
+	if !scopedReviewPermissions[req.Permission] {
+		utils.ErrorResponse(c, http.StatusBadRequest, "unsupported permission: "+req.Permission, nil)
+		return
+	}
+
+	// 博客不属于 Dapp，只能按分类指派
+	if req.Permission == "blog:review" && req.ScopeType != models.ModeratorScopeCategory {
+		utils.ErrorResponse(c, http.StatusBadRequest, "blog review can only be scoped to a category", nil)
+		return
+	}
+
+	if _, err := models.GetUserById(req.UserId); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "user not found", nil)
+		return
+	}
+
+	switch req.ScopeType {
+	case models.ModeratorScopeCategory:
+		var category models.Category
+		if err := category.GetByID(req.ScopeId); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "category not found", nil)
+			return
+		}
+	case models.ModeratorScopeDapp:
+		dapp := models.Dapp{}
+		dapp.ID = req.ScopeId
+		if err := dapp.GetByID(); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "dapp not found", nil)
+			return
+		}
+	}
+
+	assignment := models.ModeratorAssignment{
+		UserId:     req.UserId,
+		Permission: req.Permission,
+		ScopeType:  req.ScopeType,
+		ScopeId:    req.ScopeId,
+	}
+	if err := assignment.Create(); err != nil {
+		rbacError(c, err, "create")
+		return
+	}
+	recordAudit(c, AuditCreate, "moderator", assignment.ID, nil, assignment)
+	utils.SuccessResponse(c, http.StatusOK, "create success", assignment)
+}
+
+func DeleteModerator(c *gin.Context) {
+	id, ok := parseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	assignment, err := models.DeleteModeratorAssignment(id)
+	if err != nil {
+		rbacError(c, err, "delete")
+		return
+	}
+	recordAudit(c, AuditDelete, "moderator", id, assignment, nil)
+	utils.SuccessResponse(c, http.StatusOK, "delete success", nil)
+}
@@ -0,0 +1,270 @@
+package controllers
+
+import (
+	"devplaza/logger"
+	"devplaza/mailer"
+	"devplaza/models"
+	"devplaza/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+const passwordProvider = models.PasswordProvider
+
+// sendEmailToken 生成一次性令牌并通过邮件发送链接
+func sendEmailToken(user *models.User, purpose string, ttl time.Duration, path, subject, text string) error {
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	t := models.EmailToken{
+		UserId:    user.ID,
+		Purpose:   purpose,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := t.Create(); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s%s?token=%s", strings.TrimSuffix(viper.GetString("site.url"), "/"), path, token)
+	body := fmt.Sprintf("Hi %s,\n\n%s\n\n%s\n\nThis link expires in %s. If you did not request this, please ignore this email.\n", user.Username, text, link, ttl)
+	return mailer.Default().Send(user.Email, subject, body)
+}
+
+// 注册本地账号，邮箱验证后才能登录
+func Register(c *gin.Context) {
+	var req RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request. Please try again later.", nil)
+		return
+	}
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	// 邮箱已被使用时返回与注册成功相同的结果，避免泄露邮箱是否存在；
+	// 改为通知该邮箱的主人，需要时通过找回密码为该账号设置密码
+	existing := models.User{Email: email}
+	if err := models.GetUserByEmail(&existing); err == nil {
+		err = mailer.Default().Send(existing.Email, "Your DevPlaza account",
+			fmt.Sprintf("Hi %s,\n\nSomeone tried to register a DevPlaza account with this email address, which already has an account. "+
+				"If this was you, use \"Forgot password\" to set a password for it. Otherwise, you can ignore this email.\n", existing.Username))
+		if err != nil {
+			logger.Log.Errorf("send account exists email failed: %v", err)
+		}
+		utils.SuccessResponse(c, http.StatusOK, "register success, please check your email", nil)
+		return
+	}
+
+	hash, err := utils.HashPassword(req.Password)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "register fail", nil)
+		return
+	}
+
+	user := models.User{
+		Email:        email,
+		Username:     req.Username,
+		PasswordHash: hash,
+	}
+	if err := models.CreateUser(&user); err != nil {
+		logger.Log.Errorf("create user failed: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "register fail", nil)
+		return
+	}
+
+	link := models.UserIdentity{UserId: user.ID, Provider: passwordProvider, Subject: email, Email: email}
+	if err := link.Create(); err != nil {
+		logger.Log.Errorf("create identity failed: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "register fail", nil)
+		return
+	}
+
+	err = sendEmailToken(&user, models.EmailTokenVerify, 24*time.Hour, "/verify-email",
+		"Verify your DevPlaza email", "Please confirm your email address by opening the link below:")
+	if err != nil {
+		logger.Log.Errorf("send verify email failed: %v", err)
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "register success, please check your email", nil)
+}
+
+// 验证邮箱
+func VerifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request. Please try again later.", nil)
+		return
+	}
+
+	t, err := models.ConsumeEmailToken(models.EmailTokenVerify, utils.HashToken(req.Token))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid or expired token", nil)
+		return
+	}
+
+	user, err := models.GetUserById(t.UserId)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "no user", nil)
+		return
+	}
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	if err := models.UpdateUser(user); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "verify fail", nil)
+		return
+	}
+	_ = models.MarkIdentityEmailVerified(passwordProvider, user.Email)
+
+	utils.SuccessResponse(c, http.StatusOK, "email verified", nil)
+}
+
+// recordLoginFailure 记录一次密码或验证码错误，连续失败过多时锁定账号
+func recordLoginFailure(user *models.User) {
+	maxAttempts := viper.GetInt("password.maxAttempts")
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	lockFor := viper.GetDuration("password.lockDuration")
+	if lockFor <= 0 {
+		lockFor = 15 * time.Minute
+	}
+	if err := models.RecordLoginFailure(user, maxAttempts, lockFor); err != nil {
+		logger.Log.Errorf("record login failure failed: %v", err)
+	}
+}
+
+// 邮箱密码登录
+func HandlePasswordLogin(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request. Please try again later.", nil)
+		return
+	}
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	link, err := models.GetIdentity(passwordProvider, email)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "invalid email or password", nil)
+		return
+	}
+
+	user, err := models.GetUserById(link.UserId)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "invalid email or password", nil)
+		return
+	}
+
+	if user.IsLocked() {
+		utils.ErrorResponse(c, http.StatusLocked, "too many failed attempts, please try again later", nil)
+		return
+	}
+
+	if user.PasswordHash == "" || !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
+		recordLoginFailure(user)
+		utils.ErrorResponse(c, http.StatusUnauthorized, "invalid email or password", nil)
+		return
+	}
+
+	if user.EmailVerifiedAt == nil {
+		utils.ErrorResponse(c, http.StatusForbidden, "please verify your email first", nil)
+		return
+	}
+
+	if user.FailedLogins > 0 || user.LockedUntil != nil {
+		_ = models.ResetLoginFailures(user)
+	}
+
+	perms, err := models.GetUserWithPermissions(user.ID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "get permissions error", nil)
+		return
+	}
+
+	completeLogin(c, user, perms)
+}
+
+// 发送重置密码邮件，不论邮箱是否存在都返回成功
+func ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request. Please try again later.", nil)
+		return
+	}
+
+	user := models.User{Email: strings.ToLower(strings.TrimSpace(req.Email))}
+	if err := models.GetUserByEmail(&user); err == nil {
+		err = sendEmailToken(&user, models.EmailTokenReset, time.Hour, "/reset-password",
+			"Reset your DevPlaza password", "Open the link below to set a new password:")
+		if err != nil {
+			logger.Log.Errorf("send reset email failed: %v", err)
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Log.Errorf("get user failed: %v", err)
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "if the email exists, a reset link has been sent", nil)
+}
+
+// 通过邮件链接重置密码，已有账号没有密码时会同时开通密码登录
+func ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request. Please try again later.", nil)
+		return
+	}
+
+	t, err := models.ConsumeEmailToken(models.EmailTokenReset, utils.HashToken(req.Token))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid or expired token", nil)
+		return
+	}
+
+	user, err := models.GetUserById(t.UserId)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "no user", nil)
+		return
+	}
+
+	hash, err := utils.HashPassword(req.Password)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "reset fail", nil)
+		return
+	}
+
+	// 能收到邮件说明邮箱有效
+	now := time.Now()
+	user.PasswordHash = hash
+	user.EmailVerifiedAt = &now
+	user.FailedLogins = 0
+	user.LockedUntil = nil
+	if err := models.UpdateUser(user); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "reset fail", nil)
+		return
+	}
+
+	email := strings.ToLower(user.Email)
+	if _, err := models.GetIdentity(passwordProvider, email); errors.Is(err, gorm.ErrRecordNotFound) {
+		link := models.UserIdentity{UserId: user.ID, Provider: passwordProvider, Subject: email, Email: email, EmailVerified: true}
+		if err := link.Create(); err != nil {
+			logger.Log.Errorf("create identity failed: %v", err)
+		}
+	} else {
+		_ = models.MarkIdentityEmailVerified(passwordProvider, email)
+	}
+
+	// 旧会话全部失效
+	if err := models.RevokeUserTokens(user.ID); err != nil {
+		logger.Log.Errorf("revoke user tokens failed: %v", err)
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "password reset success", nil)
+}
@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"devplaza/logger"
+	"devplaza/models"
+	"devplaza/policy"
+	"devplaza/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currentSubject 当前请求的用户、已生效的权限和限定范围的审核权限
+func currentSubject(c *gin.Context) (policy.Subject, bool) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		return policy.Subject{}, false
+	}
+	userId, _ := uid.(uint)
+	perms, _ := c.Get("permissions")
+	permissions, _ := perms.([]string)
+
+	subject := policy.Subject{UserId: userId, Permissions: permissions, Mfa: c.GetBool("mfa")}
+
+	// 个人访问令牌只能使用令牌范围内的全局权限
+	if _, isApiToken := c.Get("api_token_id"); !isApiToken {
+		subject.Grants = moderatorGrants(userId)
+	}
+	return subject, true
+}
+
+// hasPermission 当前请求是否拥有某项已生效的全局权限
+func hasPermission(c *gin.Context, permission string) bool {
+	perms, _ := c.Get("permissions")
+	permissions, _ := perms.([]string)
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// subjectForUser 其他用户的权限，用于判断能否把审核任务指派给该用户。
+// 按已通过两步验证计算，实际审核时仍需通过两步验证
+func subjectForUser(userId uint) (policy.Subject, error) {
+	permissions, err := models.GetUserWithPermissions(userId)
+	if err != nil {
+		return policy.Subject{}, err
+	}
+	return policy.Subject{UserId: userId, Permissions: permissions, Grants: moderatorGrants(userId), Mfa: true}, nil
+}
+
+func moderatorGrants(userId uint) []policy.Grant {
+	assignments, err := models.ListUserModeratorAssignments(userId)
+	if err != nil {
+		logger.Log.Errorf("list moderator assignments failed: %v", err)
+	}
+	var grants []policy.Grant
+	for _, a := range assignments {
+		grants = append(grants, policy.Grant{
+			Permission: a.Permission,
+			Scope:      policy.Scope{Type: a.ScopeType, Id: a.ScopeId},
+		})
+	}
+	return grants
+}
+
+// dappScopes Dapp 及其分类（含上级分类）
+func dappScopes(dappId, categoryId uint) []policy.Scope {
+	scopes := []policy.Scope{{Type: policy.ScopeDapp, Id: dappId}}
+	for _, id := range models.CategoryLineage(categoryId) {
+		scopes = append(scopes, policy.Scope{Type: policy.ScopeCategory, Id: id})
+	}
+	return scopes
+}
+
+// contributorIds 已接受邀请的贡献者的用户 ID，内容需要预加载 Contributors
+func contributorIds(contributors []models.Contributor) []uint {
+	var ids []uint
+	for _, c := range contributors {
+		if c.AcceptedAt != nil {
+			ids = append(ids, c.UserId)
+		}
+	}
+	return ids
+}
+
+func articleResource(a *models.Article) policy.Resource {
+	r := policy.Resource{Type: policy.Blog, OwnerId: a.PublisherId, Contributors: contributorIds(a.Contributors)}
+	for _, id := range models.ArticleCategoryIds(a.Category) {
+		r.Scopes = append(r.Scopes, policy.Scope{Type: policy.ScopeCategory, Id: id})
+	}
+	return r
+}
+
+func eventResource(e *models.Event) policy.Resource {
+	return policy.Resource{Type: policy.Event, OwnerId: e.UserId}
+}
+
+func tutorialResource(t *models.Tutorial) policy.Resource {
+	r := policy.Resource{Type: policy.Tutorial, OwnerId: t.PublisherId, Contributors: contributorIds(t.Contributors)}
+	if t.DappId != nil && *t.DappId != 0 {
+		var categoryId uint
+		if t.Dapp != nil {
+			categoryId = t.Dapp.CategoryId
+		}
+		r.Scopes = dappScopes(*t.DappId, categoryId)
+	}
+	return r
+}
+
+func seriesResource(s *models.Series) policy.Resource {
+	return policy.Resource{Type: policy.Series, OwnerId: s.UserId}
+}
+
+func dappResource(d *models.Dapp) policy.Resource {
+	return policy.Resource{Type: policy.Dapp, OwnerId: d.UserId, Scopes: dappScopes(d.ID, d.CategoryId)}
+}
+
+// authorize 按统一策略检查当前用户能否操作资源，不允许时已写入响应
+func authorize(c *gin.Context, action policy.Action, resource policy.Resource) bool {
+	subject, ok := currentSubject(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
+		return false
+	}
+	if !policy.Can(subject, action, resource) {
+		utils.ErrorResponse(c, http.StatusForbidden, "permission denied", nil)
+		return false
+	}
+	return true
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"devplaza/models"
+	"devplaza/policy"
 	"devplaza/utils"
 	"net/http"
 	"strconv"
@@ -77,15 +78,7 @@ func DeletePost(c *gin.Context) {
 		return
 	}
 
-	uid, ok := c.Get("uid")
-	if !ok {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
-		return
-	}
-
-	userId, _ := uid.(uint)
-	if post.UserId != userId {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "not author", nil)
+	if !authorize(c, policy.Delete, policy.Resource{Type: policy.Post, OwnerId: post.UserId}) {
 		return
 	}
 
@@ -93,6 +86,7 @@ func DeletePost(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete post", nil)
 		return
 	}
+	recordAudit(c, AuditDelete, policy.Post, post.ID, post, nil)
 	utils.SuccessResponse(c, http.StatusOK, "delete success", nil)
 }
 
@@ -118,15 +112,7 @@ func UpdatePost(c *gin.Context) {
 		return
 	}
 
-	uid, ok := c.Get("uid")
-	if !ok {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
-		return
-	}
-
-	userId, _ := uid.(uint)
-	if post.UserId != userId {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "not author", nil)
+	if !authorize(c, policy.Update, policy.Resource{Type: policy.Post, OwnerId: post.UserId}) {
 		return
 	}
 
@@ -0,0 +1,491 @@
+package controllers
+
+import (
+	"devplaza/logger"
+	"devplaza/models"
+	"devplaza/policy"
+	"devplaza/utils"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// parseIdParam 解析路径中的 ID 参数，失败时已写入响应
+func parseIdParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil || id <= 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID", nil)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// rbacError 统一处理角色权限管理中的错误
+func rbacError(c *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "not found", nil)
+	case errors.Is(err, models.ErrPermission):
+		utils.ErrorResponse(c, http.StatusBadRequest, "permission not found", nil)
+	case errors.Is(err, models.ErrRole):
+		utils.ErrorResponse(c, http.StatusBadRequest, "role not found", nil)
+	case errors.Is(err, models.ErrNameTaken):
+		utils.ErrorResponse(c, http.StatusConflict, "name already exists", nil)
+	case errors.Is(err, models.ErrModeratorAssigned):
+		utils.ErrorResponse(c, http.StatusConflict, "moderator already assigned", nil)
+	case errors.Is(err, models.ErrRoleInUse):
+		utils.ErrorResponse(c, http.StatusConflict, "role is assigned to users", nil)
+	default:
+		logger.Log.Errorf("%s failed: %v", action, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, action+" fail", nil)
+	}
+}
+
+// 权限
+
+func ListPermissions(c *gin.Context) {
+	perms, err := models.ListPermissions()
+	if err != nil {
+		rbacError(c, err, "query")
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "query success", perms)
+}
+
+func CreatePermission(c *gin.Context) {
+	var req PermissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request", nil)
+		return
+	}
+
+	perm := models.Permission{Name: req.Name, Description: req.Description}
+	if err := perm.Create(); err != nil {
+		rbacError(c, err, "create")
+		return
+	}
+	recordAudit(c, AuditCreate, "permission", perm.ID, nil, perm)
+	utils.SuccessResponse(c, http.StatusOK, "create success", perm)
+}
+
+// 角色
+
+func ListRoles(c *gin.Context) {
+	roles, err := models.ListRoles()
+	if err != nil {
+		rbacError(c, err, "query")
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "query success", roles)
+}
+
+func GetRole(c *gin.Context) {
+	id, ok := parseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var role models.Role
+	if err := role.GetByID(id); err != nil {
+		rbacError(c, err, "query")
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "query success", role)
+}
+
+func CreateRole(c *gin.Context) {
+	var req RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request", nil)
+		return
+	}
+
+	role := models.Role{Name: req.Name, Description: req.Description}
+	if err := role.Create(); err != nil {
+		rbacError(c, err, "create")
+		return
+	}
+	recordAudit(c, AuditCreate, "role", role.ID, nil, role)
+	utils.SuccessResponse(c, http.StatusOK, "create success", role)
+}
+
+func UpdateRole(c *gin.Context) {
+	id, ok := parseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request", nil)
+		return
+	}
+
+	var role models.Role
+	if err := role.GetByID(id); err != nil {
+		rbacError(c, err, "update")
+		return
+	}
+	before := role
+	role.Name = req.Name
+	role.Description = req.Description
+	if err := role.Update(); err != nil {
+		rbacError(c, err, "update")
+		return
+	}
+	recordAudit(c, AuditUpdate, "role", role.ID, before, role)
+	utils.SuccessResponse(c, http.StatusOK, "update success", role)
+}
+
+func DeleteRole(c *gin.Context) {
+	id, ok := parseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var role models.Role
+	if err := role.GetByID(id); err != nil {
+		rbacError(c, err, "delete")
+		return
+	}
+	if err := role.Delete(); err != nil {
+		rbacError(c, err, "delete")
+		return
+	}
+	recordAudit(c, AuditDelete, "role", role.ID, role, nil)
+	utils.SuccessResponse(c, http.StatusOK, "delete success", nil)
+}
+
+func AttachRolePermissions(c *gin.Context) {
+	id, ok := parseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req AttachPermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request", nil)
+		return
+	}
+
+	var role models.Role
+	if err := role.GetByID(id); err != nil {
+		rbacError(c, err, "update")
+		return
+	}
+	if err := models.AttachRolePermissions(&role, req.PermissionIds); err != nil {
+		rbacError(c, err, "update")
+		return
+	}
+	recordAudit(c, AuditAttach, "role", role.ID, nil, req)
+	utils.SuccessResponse(c, http.StatusOK, "update success", nil)
+}
+
+func DetachRolePermission(c *gin.Context) {
+	id, ok := parseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	permissionId, ok := parseIdParam(c, "permissionId")
+	if !ok {
+		return
+	}
+
+	var role models.Role
+	if err := role.GetByID(id); err != nil {
+		rbacError(c, err, "update")
+		return
+	}
+	if err := models.DetachRolePermission(&role, permissionId); err != nil {
+		rbacError(c, err, "update")
+		return
+	}
+	recordAudit(c, AuditDetach, "role", role.ID, gin.H{"permission_id": permissionId}, nil)
+	utils.SuccessResponse(c, http.StatusOK, "update success", nil)
+}
+
+func AttachRoleGroups(c *gin.Context) {
+	id, ok := parseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req AttachGroupsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request", nil)
+		return
+	}
+
+	var role models.Role
+	if err := role.GetByID(id); err != nil {
+		rbacError(c, err, "update")
+		return
+	}
+	if err := models.AttachRoleGroups(&role, req.GroupIds); err != nil {
+		rbacError(c, err, "update")
+		return
+	}
+	recordAudit(c, AuditAttach, "role", role.ID, nil, req)
+	utils.SuccessResponse(c, http.StatusOK, "update success", nil)
+}
+
+func DetachRoleGroup(c *gin.Context) {
+	id, ok := parseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	groupId, ok := parseIdParam(c, "groupId")
+	if !ok {
+		return
+	}
+
+	var role models.Role
+	if err := role.GetByID(id); err != nil {
+		rbacError(c, err, "update")
+		return
+	}
+	if err := models.DetachRoleGroup(&role, groupId); err != nil {
+		rbacError(c, err, "update")
+		return
+	}
+	recordAudit(c, AuditDetach, "role", role.ID, gin.H{"group_id": groupId}, nil)
+	utils.SuccessResponse(c, http.StatusOK, "update success", nil)
+}
+
+// 拥有某个角色的用户
+func ListRoleUsers(c *gin.Context) {
+	id, ok := parseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	users, total, err := models.QueryUsersByRole(id, page, pageSize)
+	if err != nil {
+		rbacError(c, err, "query")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "query success", QueryRoleUsersResponse{
+		Users:    users,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// 权限组
+
+func ListPermissionGroups(c *gin.Context) {
+	groups, err := models.ListPermissionGroups()
+	if err != nil {
+		rbacError(c, err, "query")
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "query success", groups)
+}
+
+func CreatePermissionGroup(c *gin.Context) {
+	var req PermissionGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request", nil)
+		return
+	}
+
+	group := models.PermissionGroup{Name: req.Name, Description: req.Description}
+	if err := group.Create(); err != nil {
+		rbacError(c, err, "create")
+		return
+	}
+	recordAudit(c, AuditCreate, "permission_group", group.ID, nil, group)
+	utils.SuccessResponse(c, http.StatusOK, "create success", group)
+}
+
+func UpdatePermissionGroup(c *gin.Context) {
+	id, ok := parseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req PermissionGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request", nil)
+		return
+	}
+
+	var group models.PermissionGroup
+	if err := group.GetByID(id); err != nil {
+		rbacError(c, err, "update")
+		return
+	}
+	before := group
+	group.Name = req.Name
+	group.Description = req.Description
+	if err := group.Update(); err != nil {
+		rbacError(c, err, "update")
+		return
+	}
+	recordAudit(c, AuditUpdate, "permission_group", group.ID, before, group)
+	utils.SuccessResponse(c, http.StatusOK, "update success", group)
+}
+
+func DeletePermissionGroup(c *gin.Context) {
+	id, ok := parseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var group models.PermissionGroup
+	if err := group.GetByID(id); err != nil {
+		rbacError(c, err, "delete")
+		return
+	}
+	if err := group.Delete(); err != nil {
+		rbacError(c, err, "delete")
+		return
+	}
+	recordAudit(c, AuditDelete, "permission_group", group.ID, group, nil)
+	utils.SuccessResponse(c, http.StatusOK, "delete success", nil)
+}
+
+func AttachGroupPermissions(c *gin.Context) {
+	id, ok := parseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req AttachPermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request", nil)
+		return
+	}
+
+	var group models.PermissionGroup
+	if err := group.GetByID(id); err != nil {
+		rbacError(c, err, "update")
+		return
+	}
+	if err := models.AttachGroupPermissions(&group, req.PermissionIds); err != nil {
+		rbacError(c, err, "update")
+		return
+	}
+	recordAudit(c, AuditAttach, "permission_group", group.ID, nil, req)
+	utils.SuccessResponse(c, http.StatusOK, "update success", nil)
+}
+
+func DetachGroupPermission(c *gin.Context) {
+	id, ok := parseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	permissionId, ok := parseIdParam(c, "permissionId")
+	if !ok {
+		return
+	}
+
+	var group models.PermissionGroup
+	if err := group.GetByID(id); err != nil {
+		rbacError(c, err, "update")
+		return
+	}
+	if err := models.DetachGroupPermission(&group, permissionId); err != nil {
+		rbacError(c, err, "update")
+		return
+	}
+	recordAudit(c, AuditDetach, "permission_group", group.ID, gin.H{"permission_id": permissionId}, nil)
+	utils.SuccessResponse(c, http.StatusOK, "update success", nil)
+}
+
+// 用户角色
+
+func ListUserRoles(c *gin.Context) {
+	id, ok := parseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	roles, err := models.ListUserRoles(id)
+	if err != nil {
+		rbacError(c, err, "query")
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "query success", roles)
+}
+
+// 替换用户的全部角色
+func SetUserRoles(c *gin.Context) {
+	id, ok := parseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req SetUserRolesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request", nil)
+		return
+	}
+
+	before, err := models.ListUserRoles(id)
+	if err != nil {
+		rbacError(c, err, "update")
+		return
+	}
+
+	if err := models.SetUserRoles(id, req.RoleIds); err != nil {
+		rbacError(c, err, "update")
+		return
+	}
+	recordAudit(c, AuditSetRoles, policy.User, id, roleIds(before), req.RoleIds)
+	utils.SuccessResponse(c, http.StatusOK, "update success", nil)
+}
+
+func AddUserRoles(c *gin.Context) {
+	id, ok := parseIdParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req AddUserRolesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request", nil)
+		return
+	}
+
+	if err := models.AddUserRoles(id, req.RoleIds); err != nil {
+		rbacError(c, err, "update")
+		return
+	}
+	recordAudit(c, AuditAttach, "user_role", id, nil, req.RoleIds)
+	utils.SuccessResponse(c, http.StatusOK, "update success", nil)
+}
+
+func RemoveUserRole(c *gin.Context) {
+	id, ok := parseIdParam(c, "id")
+	if !ok {
+		return
+	}
+	roleId, ok := parseIdParam(c, "roleId")
+	if !ok {
+		return
+	}
+
+	if err := models.RemoveUserRole(id, roleId); err != nil {
+		rbacError(c, err, "update")
+		return
+	}
+	recordAudit(c, AuditDetach, "user_role", id, gin.H{"role_id": roleId}, nil)
+	utils.SuccessResponse(c, http.StatusOK, "update success", nil)
+}
+
+func roleIds(roles []models.Role) []uint {
+	ids := make([]uint, 0, len(roles))
+	for _, r := range roles {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
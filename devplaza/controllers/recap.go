@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"devplaza/models"
+	"devplaza/policy"
 	"devplaza/utils"
 	"fmt"
 	"net/http"
@@ -99,15 +100,7 @@ func UpdateRecap(c *gin.Context) {
 		return
 	}
 
-	uid, ok := c.Get("uid")
-	if !ok {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
-		return
-	}
-
-	userId, _ := uid.(uint)
-	if userId != recap.UserId {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "not author", nil)
+	if !authorize(c, policy.Update, policy.Resource{Type: policy.Recap, OwnerId: recap.UserId}) {
 		return
 	}
 
@@ -150,15 +143,7 @@ func DeleteRecap(c *gin.Context) {
 		return
 	}
 
-	uid, ok := c.Get("uid")
-	if !ok {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
-		return
-	}
-
-	userId, _ := uid.(uint)
-	if userId != recap.UserId {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "not author", nil)
+	if !authorize(c, policy.Delete, policy.Resource{Type: policy.Recap, OwnerId: recap.UserId}) {
 		return
 	}
 
@@ -166,5 +151,6 @@ func DeleteRecap(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete recap", nil)
 		return
 	}
+	recordAudit(c, AuditDelete, policy.Recap, recap.ID, recap, nil)
 	utils.SuccessResponse(c, http.StatusOK, "delete success", nil)
 }
@@ -0,0 +1,332 @@
+package controllers
+
+import (
+	"devplaza/logger"
+	"devplaza/models"
+	"devplaza/policy"
+	"devplaza/utils"
+	"devplaza/workflow"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// reviewTarget 审核队列中的一条内容
+type reviewTarget struct {
+	contentType string
+	id          uint
+	status      uint
+	resource    policy.Resource
+}
+
+// loadReviewTarget 按路径参数 :type、:id 读取内容，失败时已写入响应
+func loadReviewTarget(c *gin.Context) (*reviewTarget, bool) {
+	switch c.Param("type") {
+	case policy.Blog:
+		article, ok := loadArticle(c)
+		if !ok {
+			return nil, false
+		}
+		return &reviewTarget{policy.Blog, article.ID, article.PublishStatus, articleResource(article)}, true
+	case policy.Tutorial:
+		tutorial, ok := loadTutorial(c)
+		if !ok {
+			return nil, false
+		}
+		return &reviewTarget{policy.Tutorial, tutorial.ID, tutorial.PublishStatus, tutorialResource(tutorial)}, true
+	case policy.Event:
+		event, ok := loadEvent(c)
+		if !ok {
+			return nil, false
+		}
+		return &reviewTarget{policy.Event, event.ID, event.PublishStatus, eventResource(event)}, true
+	case policy.Dapp:
+		dapp, ok := loadDapp(c)
+		if !ok {
+			return nil, false
+		}
+		return &reviewTarget{policy.Dapp, dapp.ID, dapp.PublishStatus, dappResource(dapp)}, true
+	}
+	utils.ErrorResponse(c, http.StatusBadRequest, "Invalid content type", nil)
+	return nil, false
+}
+
+// awaitingReview 内容在审核队列中时才能认领、指派，否则已写入响应
+func awaitingReview(c *gin.Context, target *reviewTarget) bool {
+	if s := workflow.Status(target.status); s != workflow.PendingReview && s != workflow.Scheduled {
+		utils.ErrorResponse(c, http.StatusBadRequest, "content is not awaiting review", nil)
+		return false
+	}
+	return true
+}
+
+// reviewQueueScopes 用户可审核的内容类型和范围
+func reviewQueueScopes(subject policy.Subject) []models.ReviewQueueScope {
+	var scopes []models.ReviewQueueScope
+	for _, contentType := range []string{policy.Blog, policy.Tutorial, policy.Event, policy.Dapp} {
+		all, grants := policy.ReviewScopes(subject, contentType)
+		if all {
+			scopes = append(scopes, models.ReviewQueueScope{ContentType: contentType, All: true})
+			continue
+		}
+		if len(grants) == 0 {
+			continue
+		}
+		scope := models.ReviewQueueScope{ContentType: contentType}
+		for _, g := range grants {
+			switch g.Type {
+			case policy.ScopeDapp:
+				scope.DappIds = append(scope.DappIds, g.Id)
+			case policy.ScopeCategory:
+				scope.CategoryIds = append(scope.CategoryIds, g.Id)
+			}
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes
+}
+
+// GetReviewQueue 当前用户可审核的待审核、等待定时发布的内容和待收录的 Dapp，最早提交的在前
+func GetReviewQueue(c *gin.Context) {
+	subject, ok := currentSubject(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+	scopes := reviewQueueScopes(subject)
+	if len(scopes) == 0 {
+		utils.ErrorResponse(c, http.StatusForbidden, "permission denied", nil)
+		return
+	}
+
+	page, pageSize := pageParams(c)
+
+	filter := models.ReviewQueueFilter{
+		Scopes:      scopes,
+		ContentType: c.Query("content_type"),
+		Statuses:    []uint{uint(workflow.PendingReview), uint(workflow.Scheduled)},
+		Page:        page,
+		PageSize:    pageSize,
+	}
+	// status 只能缩小到队列中的某一种状态
+	if status := c.Query("status"); status != "" {
+		s, err := strconv.Atoi(status)
+		if err != nil || (workflow.Status(s) != workflow.PendingReview && workflow.Status(s) != workflow.Scheduled) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid status", nil)
+			return
+		}
+		filter.Statuses = []uint{uint(s)}
+	}
+	// assignee：me 为自己负责的，none 为未指派的，也可以是审核员的用户 ID
+	switch assignee := c.Query("assignee"); assignee {
+	case "":
+	case "me":
+		filter.AssigneeId = subject.UserId
+	case "none":
+		filter.Unassigned = true
+	default:
+		id, err := strconv.Atoi(assignee)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid assignee", nil)
+			return
+		}
+		filter.AssigneeId = uint(id)
+	}
+
+	items, total, err := models.QueryReviewQueue(filter)
+	if err != nil {
+		logger.Log.Errorf("query review queue failed: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to query review queue", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "success", QueryReviewQueueResponse{
+		Items:    items,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// ClaimReview 审核员认领内容
+func ClaimReview(c *gin.Context) {
+	target, ok := loadReviewTarget(c)
+	if !ok {
+		return
+	}
+	if !authorize(c, policy.Review, target.resource) {
+		return
+	}
+	if !awaitingReview(c, target) {
+		return
+	}
+
+	uid, _ := c.Get("uid")
+	userId, _ := uid.(uint)
+	assignment, err := models.ClaimReview(target.contentType, target.id, userId)
+	if err != nil {
+		if errors.Is(err, models.ErrReviewAssigned) {
+			utils.ErrorResponse(c, http.StatusConflict, "already assigned to another reviewer", nil)
+			return
+		}
+		logger.Log.Errorf("claim %s %d review failed: %v", target.contentType, target.id, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to claim review", nil)
+		return
+	}
+	recordAudit(c, AuditAssign, target.contentType, target.id, nil, gin.H{"reviewer_id": userId})
+	utils.SuccessResponse(c, http.StatusOK, "success", assignment)
+}
+
+// AssignReview 将内容指派给能审核它的审核员
+func AssignReview(c *gin.Context) {
+	target, ok := loadReviewTarget(c)
+	if !ok {
+		return
+	}
+	if !authorize(c, policy.Review, target.resource) {
+		return
+	}
+	if !awaitingReview(c, target) {
+		return
+	}
+
+	var req AssignReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid input data", nil)
+		return
+	}
+	reviewer, err := subjectForUser(req.ReviewerId)
+	if err != nil || !policy.Can(reviewer, policy.Review, target.resource) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "user can not review this content", nil)
+		return
+	}
+
+	var before *models.ReviewAssignment
+	if existing, err := models.GetReviewAssignment(target.contentType, target.id); err == nil {
+		before = existing
+	}
+
+	uid, _ := c.Get("uid")
+	userId, _ := uid.(uint)
+	assignment, err := models.AssignReview(target.contentType, target.id, req.ReviewerId, userId)
+	if err != nil {
+		logger.Log.Errorf("assign %s %d review failed: %v", target.contentType, target.id, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to assign review", nil)
+		return
+	}
+	recordAudit(c, AuditAssign, target.contentType, target.id, before, gin.H{"reviewer_id": req.ReviewerId})
+	utils.SuccessResponse(c, http.StatusOK, "success", assignment)
+}
+
+// UnassignReview 取消指派，负责的审核员本人或其他审核员都可以取消
+func UnassignReview(c *gin.Context) {
+	target, ok := loadReviewTarget(c)
+	if !ok {
+		return
+	}
+
+	uid, _ := c.Get("uid")
+	userId, _ := uid.(uint)
+	existing, err := models.GetReviewAssignment(target.contentType, target.id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.SuccessResponse(c, http.StatusOK, "success", nil)
+		return
+	}
+	if err != nil {
+		logger.Log.Errorf("get %s %d review assignment failed: %v", target.contentType, target.id, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to unassign review", nil)
+		return
+	}
+	if existing.ReviewerId != userId && !authorize(c, policy.Review, target.resource) {
+		return
+	}
+
+	if err := models.UnassignReview(target.contentType, target.id); err != nil {
+		logger.Log.Errorf("unassign %s %d review failed: %v", target.contentType, target.id, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to unassign review", nil)
+		return
+	}
+	recordAudit(c, AuditUnassign, target.contentType, target.id, existing, nil)
+	utils.SuccessResponse(c, http.StatusOK, "success", nil)
+}
+
+// authorizeReviewDiscussion 作者和审核员可以查看、发表审核意见，不允许时已写入响应
+func authorizeReviewDiscussion(c *gin.Context, target *reviewTarget) bool {
+	subject, ok := currentSubject(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
+		return false
+	}
+	if len(publishActors(subject, target.resource)) == 0 {
+		utils.ErrorResponse(c, http.StatusForbidden, "permission denied", nil)
+		return false
+	}
+	return true
+}
+
+func ListReviewComments(c *gin.Context) {
+	target, ok := loadReviewTarget(c)
+	if !ok {
+		return
+	}
+	if !authorizeReviewDiscussion(c, target) {
+		return
+	}
+
+	comments, err := models.ListReviewComments(target.contentType, target.id)
+	if err != nil {
+		logger.Log.Errorf("list %s %d review comments failed: %v", target.contentType, target.id, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to list comments", nil)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "success", comments)
+}
+
+// CreateReviewComment 发表审核意见或回复，作者可以在重新提交前回复审核员
+func CreateReviewComment(c *gin.Context) {
+	target, ok := loadReviewTarget(c)
+	if !ok {
+		return
+	}
+	if !authorizeReviewDiscussion(c, target) {
+		return
+	}
+
+	var req CreateReviewCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid input data", nil)
+		return
+	}
+
+	version := req.Version
+	if version == 0 {
+		if latest, err := models.GetRevision(target.contentType, target.id, 0); err == nil {
+			version = latest.Version
+		}
+	}
+
+	uid, _ := c.Get("uid")
+	userId, _ := uid.(uint)
+	comment := models.ReviewComment{
+		ContentType: target.contentType,
+		ContentId:   target.id,
+		UserId:      userId,
+		ParentId:    req.ParentId,
+		Version:     version,
+		Anchor:      req.Anchor,
+		Body:        req.Body,
+	}
+	if err := comment.Create(); err != nil {
+		if errors.Is(err, models.ErrInvalidParentComment) {
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		logger.Log.Errorf("create %s %d review comment failed: %v", target.contentType, target.id, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create comment", nil)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "success", comment)
+}
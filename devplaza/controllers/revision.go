@@ -0,0 +1,258 @@
+package controllers
+
+import (
+	"devplaza/logger"
+	"devplaza/models"
+	"devplaza/policy"
+	"devplaza/utils"
+	"devplaza/workflow"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func loadArticle(c *gin.Context) (*models.Article, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID", nil)
+		return nil, false
+	}
+	var article models.Article
+	if err := article.Load(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid article", nil)
+		return nil, false
+	}
+	return &article, true
+}
+
+func loadTutorial(c *gin.Context) (*models.Tutorial, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID", nil)
+		return nil, false
+	}
+	var tutorial models.Tutorial
+	tutorial.ID = uint(id)
+	if err := tutorial.Load(); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid tutorial", nil)
+		return nil, false
+	}
+	return &tutorial, true
+}
+
+// authorizeRevisions 可编辑或审核该内容的用户才能查看修订历史，不允许时已写入响应
+func authorizeRevisions(c *gin.Context, resource policy.Resource) bool {
+	subject, ok := currentSubject(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
+		return false
+	}
+	if !policy.Can(subject, policy.Update, resource) && !policy.Can(subject, policy.Review, resource) {
+		utils.ErrorResponse(c, http.StatusForbidden, "permission denied", nil)
+		return false
+	}
+	return true
+}
+
+// findRevision 按版本号查找修订版本，ref 为 approved 时返回最近审核通过的版本，失败时已写入响应
+func findRevision(c *gin.Context, contentType string, id uint, ref string) (*models.Revision, bool) {
+	var revision *models.Revision
+	var err error
+	if ref == "approved" {
+		revision, err = models.GetApprovedRevision(contentType, id)
+	} else {
+		version, convErr := strconv.Atoi(ref)
+		if convErr != nil || version < 1 {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid version", nil)
+			return nil, false
+		}
+		revision, err = models.GetRevision(contentType, id, version)
+	}
+	if err != nil {
+		if errors.Is(err, models.ErrRevisionNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "revision not found", nil)
+			return nil, false
+		}
+		logger.Log.Errorf("get %s %d revision %s failed: %v", contentType, id, ref, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get revision", nil)
+		return nil, false
+	}
+	return revision, true
+}
+
+func listRevisions(c *gin.Context, contentType string, id uint, resource policy.Resource) {
+	if !authorizeRevisions(c, resource) {
+		return
+	}
+	revisions, err := models.ListRevisions(contentType, id)
+	if err != nil {
+		logger.Log.Errorf("list %s %d revisions failed: %v", contentType, id, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to list revisions", nil)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "success", revisions)
+}
+
+func getRevision(c *gin.Context, contentType string, id uint, resource policy.Resource) {
+	if !authorizeRevisions(c, resource) {
+		return
+	}
+	revision, ok := findRevision(c, contentType, id, c.Param("version"))
+	if !ok {
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "success", revision)
+}
+
+// diffRevisions 比较两个版本的正文。to 默认为最新版本；
+// from 默认为最近审核通过的版本，没有时为 to 的上一个版本
+func diffRevisions(c *gin.Context, contentType string, id uint, resource policy.Resource) {
+	if !authorizeRevisions(c, resource) {
+		return
+	}
+
+	var to *models.Revision
+	if ref := c.Query("to"); ref != "" {
+		var ok bool
+		if to, ok = findRevision(c, contentType, id, ref); !ok {
+			return
+		}
+	} else {
+		latest, err := models.GetRevision(contentType, id, 0)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusNotFound, "revision not found", nil)
+			return
+		}
+		to = latest
+	}
+
+	from := &models.Revision{}
+	if ref := c.Query("from"); ref != "" {
+		var ok bool
+		if from, ok = findRevision(c, contentType, id, ref); !ok {
+			return
+		}
+	} else if approved, err := models.GetApprovedRevision(contentType, id); err == nil {
+		from = approved
+	} else if to.Version > 1 {
+		if previous, err := models.GetRevision(contentType, id, to.Version-1); err == nil {
+			from = previous
+		}
+	}
+
+	lines := utils.LineDiff(from.Content, to.Content)
+	from.Content, to.Content = "", ""
+	utils.SuccessResponse(c, http.StatusOK, "success", RevisionDiffResponse{From: from, To: to, Lines: lines})
+}
+
+func ListArticleRevisions(c *gin.Context) {
+	article, ok := loadArticle(c)
+	if !ok {
+		return
+	}
+	listRevisions(c, policy.Blog, article.ID, articleResource(article))
+}
+
+func GetArticleRevision(c *gin.Context) {
+	article, ok := loadArticle(c)
+	if !ok {
+		return
+	}
+	getRevision(c, policy.Blog, article.ID, articleResource(article))
+}
+
+func DiffArticleRevisions(c *gin.Context) {
+	article, ok := loadArticle(c)
+	if !ok {
+		return
+	}
+	diffRevisions(c, policy.Blog, article.ID, articleResource(article))
+}
+
+// RestoreArticleRevision 用历史版本的标题、简介和正文生成一个新版本，已发布的博客需要重新审核
+func RestoreArticleRevision(c *gin.Context) {
+	article, ok := loadArticle(c)
+	if !ok {
+		return
+	}
+	if !authorize(c, policy.Update, articleResource(article)) {
+		return
+	}
+	revision, ok := findRevision(c, policy.Blog, article.ID, c.Param("version"))
+	if !ok {
+		return
+	}
+
+	article.Title = revision.Title
+	article.Description = revision.Description
+	article.Content = revision.Content
+	from := article.PublishStatus
+	article.PublishStatus = uint(workflow.AfterEdit(workflow.Status(from)))
+
+	uid, _ := c.Get("uid")
+	userId, _ := uid.(uint)
+	if err := article.SaveWithRevision(userId); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to restore article", nil)
+		return
+	}
+	recordEditTransition(c, policy.Blog, article.ID, from, article.PublishStatus)
+	recordAudit(c, AuditRestore, policy.Blog, article.ID, nil, gin.H{"version": revision.Version})
+	utils.SuccessResponse(c, http.StatusOK, "success", article)
+}
+
+func ListTutorialRevisions(c *gin.Context) {
+	tutorial, ok := loadTutorial(c)
+	if !ok {
+		return
+	}
+	listRevisions(c, policy.Tutorial, tutorial.ID, tutorialResource(tutorial))
+}
+
+func GetTutorialRevision(c *gin.Context) {
+	tutorial, ok := loadTutorial(c)
+	if !ok {
+		return
+	}
+	getRevision(c, policy.Tutorial, tutorial.ID, tutorialResource(tutorial))
+}
+
+func DiffTutorialRevisions(c *gin.Context) {
+	tutorial, ok := loadTutorial(c)
+	if !ok {
+		return
+	}
+	diffRevisions(c, policy.Tutorial, tutorial.ID, tutorialResource(tutorial))
+}
+
+// RestoreTutorialRevision 用历史版本的标题、简介和正文生成一个新版本，已发布的教程需要重新审核
+func RestoreTutorialRevision(c *gin.Context) {
+	tutorial, ok := loadTutorial(c)
+	if !ok {
+		return
+	}
+	if !authorize(c, policy.Update, tutorialResource(tutorial)) {
+		return
+	}
+	revision, ok := findRevision(c, policy.Tutorial, tutorial.ID, c.Param("version"))
+	if !ok {
+		return
+	}
+
+	tutorial.Title = revision.Title
+	tutorial.Description = revision.Description
+	tutorial.Content = revision.Content
+	from := tutorial.PublishStatus
+	tutorial.PublishStatus = uint(workflow.AfterEdit(workflow.Status(from)))
+
+	uid, _ := c.Get("uid")
+	userId, _ := uid.(uint)
+	if err := tutorial.SaveWithRevision(userId); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to restore tutorial", nil)
+		return
+	}
+	recordEditTransition(c, policy.Tutorial, tutorial.ID, from, tutorial.PublishStatus)
+	recordAudit(c, AuditRestore, policy.Tutorial, tutorial.ID, nil, gin.H{"version": revision.Version})
+	utils.SuccessResponse(c, http.StatusOK, "success", tutorial)
+}
@@ -0,0 +1,251 @@
+package controllers
+
+import (
+	"devplaza/logger"
+	"devplaza/models"
+	"devplaza/policy"
+	"devplaza/utils"
+	"devplaza/workflow"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CreateSeries(c *gin.Context) {
+	var req CreateSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid input data", nil)
+		return
+	}
+
+	uid, _ := c.Get("uid")
+	userId, _ := uid.(uint)
+	series := models.Series{
+		Title:       req.Title,
+		Description: req.Desc,
+		CoverImg:    req.CoverImg,
+		UserId:      userId,
+	}
+	if err := series.Create(); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create series", nil)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "create success", series)
+}
+
+// GetSeries 系列和章节，作者和审核员可以看到未发布的章节
+func GetSeries(c *gin.Context) {
+	series, ok := loadSeries(c)
+	if !ok {
+		return
+	}
+
+	subject, _ := currentSubject(c)
+	if !policy.Can(subject, policy.Update, seriesResource(series)) {
+		published := []models.Tutorial{}
+		for _, t := range series.Tutorials {
+			if workflow.Status(t.PublishStatus) == workflow.Published {
+				published = append(published, t)
+			}
+		}
+		series.Tutorials = published
+	}
+	utils.SuccessResponse(c, http.StatusOK, "success", series)
+}
+
+func QuerySeries(c *gin.Context) {
+	keyword := c.Query("keyword")
+	userId, _ := strconv.Atoi(c.Query("user_id"))
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "6"))
+
+	filter := models.SeriesFilter{
+		Keyword:  keyword,
+		UserId:   uint(userId),
+		Page:     page,
+		PageSize: pageSize,
+	}
+
+	series, total, err := models.QuerySeries(filter)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "query success", QuerySeriesResponse{
+		Series:   series,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+func UpdateSeries(c *gin.Context) {
+	series, ok := loadSeries(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid input data", nil)
+		return
+	}
+
+	if !authorize(c, policy.Update, seriesResource(series)) {
+		return
+	}
+
+	series.Title = req.Title
+	series.Description = req.Desc
+	series.CoverImg = req.CoverImg
+	if err := series.Update(); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update series", nil)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "success", series)
+}
+
+// DeleteSeries 删除系列，其中的教程不会被删除
+func DeleteSeries(c *gin.Context) {
+	series, ok := loadSeries(c)
+	if !ok {
+		return
+	}
+	if !authorize(c, policy.Delete, seriesResource(series)) {
+		return
+	}
+
+	if err := series.Delete(); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete series", nil)
+		return
+	}
+	recordAudit(c, AuditDelete, policy.Series, series.ID, series, nil)
+	utils.SuccessResponse(c, http.StatusOK, "delete success", nil)
+}
+
+// AddSeriesTutorial 将教程加入系列末尾，需要同时能编辑系列和该教程
+func AddSeriesTutorial(c *gin.Context) {
+	series, ok := loadSeries(c)
+	if !ok {
+		return
+	}
+
+	var req AddSeriesTutorialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid input data", nil)
+		return
+	}
+
+	var tutorial models.Tutorial
+	tutorial.ID = req.TutorialId
+	if err := tutorial.Load(); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid tutorial", nil)
+		return
+	}
+
+	if !authorize(c, policy.Update, seriesResource(series)) || !authorize(c, policy.Update, tutorialResource(&tutorial)) {
+		return
+	}
+
+	if err := models.AddSeriesTutorial(series.ID, tutorial.ID); err != nil {
+		if errors.Is(err, models.ErrTutorialInSeries) {
+			utils.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+			return
+		}
+		logger.Log.Errorf("add tutorial %d to series %d failed: %v", tutorial.ID, series.ID, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to add tutorial", nil)
+		return
+	}
+	respondSeries(c, series.ID)
+}
+
+// RemoveSeriesTutorial 将教程移出系列，系列作者和教程作者都可以移出
+func RemoveSeriesTutorial(c *gin.Context) {
+	series, ok := loadSeries(c)
+	if !ok {
+		return
+	}
+
+	tutorialId, err := strconv.Atoi(c.Param("tutorialId"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid tutorial ID", nil)
+		return
+	}
+
+	subject, _ := currentSubject(c)
+	if !policy.Can(subject, policy.Update, seriesResource(series)) {
+		var tutorial models.Tutorial
+		tutorial.ID = uint(tutorialId)
+		if err := tutorial.Load(); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid tutorial", nil)
+			return
+		}
+		if !authorize(c, policy.Update, tutorialResource(&tutorial)) {
+			return
+		}
+	}
+
+	if err := models.RemoveSeriesTutorial(series.ID, uint(tutorialId)); err != nil {
+		logger.Log.Errorf("remove tutorial %d from series %d failed: %v", tutorialId, series.ID, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to remove tutorial", nil)
+		return
+	}
+	respondSeries(c, series.ID)
+}
+
+// ReorderSeries 调整章节顺序
+func ReorderSeries(c *gin.Context) {
+	series, ok := loadSeries(c)
+	if !ok {
+		return
+	}
+
+	var req ReorderSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid input data", nil)
+		return
+	}
+
+	if !authorize(c, policy.Update, seriesResource(series)) {
+		return
+	}
+
+	if err := models.ReorderSeries(series.ID, req.TutorialIds); err != nil {
+		if errors.Is(err, models.ErrInvalidSeriesOrder) {
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		logger.Log.Errorf("reorder series %d failed: %v", series.ID, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to reorder series", nil)
+		return
+	}
+	respondSeries(c, series.ID)
+}
+
+// respondSeries 返回修改后的系列和全部章节
+func respondSeries(c *gin.Context, id uint) {
+	var series models.Series
+	if err := series.GetByID(id); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get series", nil)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "success", series)
+}
+
+func loadSeries(c *gin.Context) (*models.Series, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID", nil)
+		return nil, false
+	}
+	var series models.Series
+	if err := series.GetByID(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid series", nil)
+		return nil, false
+	}
+	return &series, true
+}
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"devplaza/logger"
+	"devplaza/models"
+	"devplaza/utils"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 当前用户已登录的设备
+func ListMySessions(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+	userId, _ := uid.(uint)
+
+	sessions, err := models.ListUserSessions(userId)
+	if err != nil {
+		logger.Log.Errorf("list sessions failed: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "internal error", nil)
+		return
+	}
+
+	sid := c.GetString("sid")
+	for i := range sessions {
+		sessions[i].Current = sessions[i].FamilyId == sid
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "success", sessions)
+}
+
+// 在指定设备上退出登录
+func EndMySession(c *gin.Context) {
+	if rejectApiToken(c) {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID", nil)
+		return
+	}
+
+	uid, ok := c.Get("uid")
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+	userId, _ := uid.(uint)
+
+	if err := models.EndUserSession(userId, uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "session not found", nil)
+			return
+		}
+		logger.Log.Errorf("end session failed: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "sign out fail", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "sign out success", nil)
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"devplaza/identity"
+	"devplaza/logger"
+	"devplaza/models"
+	"devplaza/utils"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+// 获取 SIWE 登录随机数
+func GetSiweNonce(c *gin.Context) {
+	nonce, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "generate nonce error", nil)
+		return
+	}
+
+	n := models.SiweNonce{
+		Nonce:     nonce,
+		ExpiresAt: time.Now().Add(10 * time.Minute),
+	}
+	if err := n.Create(); err != nil {
+		logger.Log.Errorf("create siwe nonce failed: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "generate nonce error", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "success", SiweNonceResponse{Nonce: nonce})
+}
+
+// verifySiwe 校验 SIWE 消息与签名，返回签名者地址
+func verifySiwe(req SiweLoginRequest) (string, error) {
+	msg, err := utils.ParseSiweMessage(req.Message)
+	if err != nil {
+		return "", err
+	}
+
+	// 未配置域名时拒绝所有登录，防止其他站点诱导用户签名的消息被重放到这里
+	domain := viper.GetString("siwe.domain")
+	if domain == "" {
+		return "", errors.New("siwe.domain is not configured")
+	}
+	if msg.Domain != domain {
+		return "", errors.New("domain mismatch")
+	}
+	if chainId := viper.GetInt64("siwe.chainId"); chainId != 0 && msg.ChainID != chainId {
+		return "", errors.New("chain id mismatch")
+	}
+	if err := msg.Validate(time.Now()); err != nil {
+		return "", err
+	}
+
+	address, err := utils.RecoverPersonalSignAddress(req.Message, req.Signature)
+	if err != nil {
+		return "", err
+	}
+	if address != msg.Address {
+		return "", errors.New("signature mismatch")
+	}
+
+	// 签名通过后再消费随机数，防止重放
+	if err := models.ConsumeSiweNonce(msg.Nonce); err != nil {
+		return "", err
+	}
+
+	return address, nil
+}
+
+func siweIdentity(address string) *identity.Identity {
+	return &identity.Identity{
+		Provider: "siwe",
+		Subject:  address,
+		Username: address[:6] + "..." + address[len(address)-4:],
+		Address:  address,
+	}
+}
+
+// 使用以太坊钱包登录（EIP-4361）
+func HandleSiweLogin(c *gin.Context) {
+	var req SiweLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Log.Errorf("Invalid request: %v", err)
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request. Please try again later.", nil)
+		return
+	}
+
+	address, err := verifySiwe(req)
+	if err != nil {
+		logger.Log.Warnf("siwe verify failed: %v", err)
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Authentication failed, please try again.", nil)
+		return
+	}
+
+	user, err := resolveIdentityUser(siweIdentity(address))
+	if err != nil {
+		logger.Log.Errorf("ServerError: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Network error, please try again later.", nil)
+		return
+	}
+
+	perms, err := models.GetUserWithPermissions(user.ID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "get permissions error", nil)
+		return
+	}
+
+	completeLogin(c, user, perms)
+}
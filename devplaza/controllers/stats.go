@@ -16,3 +16,8 @@ func StatsOverview(c *gin.Context) {
 	}
 	utils.SuccessResponse(c, http.StatusOK, "query success", overview)
 }
+
+// 权限缓存命中率
+func PermissionCacheStats(c *gin.Context) {
+	utils.SuccessResponse(c, http.StatusOK, "query success", models.PermissionCacheStats())
+}
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"devplaza/logger"
+	"devplaza/models"
+	"devplaza/utils"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// completeLogin 第一步登录成功后调用；开启了两步验证的用户先返回挑战令牌
+func completeLogin(c *gin.Context, user *models.User, perms []string) {
+	if user.TotpEnabled {
+		mfaToken, err := utils.GenerateMfaToken(user.ID)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "generate token error", nil)
+			return
+		}
+		utils.SuccessResponse(c, http.StatusOK, "two-factor authentication required", MfaChallengeResponse{
+			MfaRequired: true,
+			MfaToken:    mfaToken,
+		})
+		return
+	}
+
+	loginResp, err := issueTokens(c, user, perms, false)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "generate token error", nil)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "success", loginResp)
+}
+
+// issueTokens 登录成功后开启新的会话和令牌族，签发访问令牌和刷新令牌
+func issueTokens(c *gin.Context, user *models.User, perms []string, mfa bool) (*LoginResponse, error) {
+	sid, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	rt := models.RefreshToken{
+		UserId:    user.ID,
+		FamilyId:  sid,
+		TokenHash: utils.HashToken(refreshToken),
+		ExpiresAt: time.Now().Add(utils.RefreshTokenTTL()),
+	}
+	if err := rt.Create(); err != nil {
+		return nil, err
+	}
+
+	session := models.Session{
+		UserId:      user.ID,
+		FamilyId:    sid,
+		UserAgent:   c.Request.UserAgent(),
+		Ip:          c.ClientIP(),
+		LastSeenAt:  time.Now(),
+		MfaVerified: mfa,
+	}
+	if err := session.Create(); err != nil {
+		return nil, err
+	}
+
+	return buildLoginResponse(user, perms, sid, refreshToken, mfa)
+}
+
+// buildLoginResponse 为指定令牌族签发访问令牌
+func buildLoginResponse(user *models.User, perms []string, sid, refreshToken string, mfa bool) (*LoginResponse, error) {
+	roles, err := models.GetUserRoleNames(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := utils.GenerateToken(utils.Claims{
+		Uid:         user.ID,
+		Email:       user.Email,
+		Avatar:      user.Avatar,
+		Username:    user.Username,
+		Github:      user.Github,
+		Roles:       roles,
+		Permissions: perms,
+		Sid:         sid,
+		Mfa:         mfa,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResponse{
+		User:         *user,
+		Roles:        roles,
+		Permissions:  perms,
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(utils.AccessTokenTTL().Seconds()),
+	}, nil
+}
+
+// 刷新令牌：轮换刷新令牌并签发新的访问令牌
+func RefreshToken(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request. Please try again later.", nil)
+		return
+	}
+
+	refreshToken, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "generate token error", nil)
+		return
+	}
+
+	rt, err := models.RotateRefreshToken(utils.HashToken(req.RefreshToken), utils.HashToken(refreshToken), time.Now().Add(utils.RefreshTokenTTL()))
+	if err != nil {
+		if errors.Is(err, models.ErrRefreshTokenReused) {
+			logger.Log.Warnf("refresh token reused, family revoked")
+		}
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Please log in to continue!", nil)
+		return
+	}
+
+	// 两步验证状态随会话保留
+	var mfa bool
+	if session, err := models.GetActiveSession(rt.FamilyId); err == nil {
+		mfa = session.MfaVerified
+		_ = models.TouchSession(session, c.ClientIP())
+	}
+
+	user, err := models.GetUserById(rt.UserId)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Please log in to continue!", nil)
+		return
+	}
+
+	perms, err := models.GetUserWithPermissions(user.ID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "get permissions error", nil)
+		return
+	}
+
+	resp, err := buildLoginResponse(user, perms, rt.FamilyId, refreshToken, mfa)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "generate token error", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "success", resp)
+}
+
+// 退出登录：结束当前会话并吊销令牌族
+func Logout(c *gin.Context) {
+	sid := c.GetString("sid")
+	if err := models.RevokeTokenFamily(sid); err != nil {
+		logger.Log.Errorf("revoke token family failed: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "logout fail", nil)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "logout success", nil)
+}
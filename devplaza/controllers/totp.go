@@ -0,0 +1,282 @@
+package controllers
+
+import (
+	"devplaza/logger"
+	"devplaza/models"
+	"devplaza/utils"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+const recoveryCodeCount = 10
+
+// generateRecoveryCodes 生成恢复码，返回明文和摘要
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw, err := utils.GenerateRandomToken(5)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, utils.HashToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+// verifySecondFactor 校验 TOTP 验证码或恢复码
+func verifySecondFactor(user *models.User, code string) bool {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	if code == "" {
+		return false
+	}
+
+	if step, ok := utils.ValidateTotp(user.TotpSecret, code, time.Now()); ok {
+		return models.UseTotpStep(user, step) == nil
+	}
+	return models.ConsumeRecoveryCode(user.ID, utils.HashToken(code)) == nil
+}
+
+// checkSecondFactor 校验验证码或恢复码，与密码共用失败计数和锁定，失败时已写入响应
+func checkSecondFactor(c *gin.Context, user *models.User, code string, failStatus int) bool {
+	if user.IsLocked() {
+		utils.ErrorResponse(c, http.StatusLocked, "too many failed attempts, please try again later", nil)
+		return false
+	}
+	if !verifySecondFactor(user, code) {
+		recordLoginFailure(user)
+		utils.ErrorResponse(c, failStatus, "invalid verification code", nil)
+		return false
+	}
+	if user.FailedLogins > 0 || user.LockedUntil != nil {
+		_ = models.ResetLoginFailures(user)
+	}
+	return true
+}
+
+// loadCurrentUser 读取当前登录用户，失败时已写入响应
+func loadCurrentUser(c *gin.Context) (*models.User, bool) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
+		return nil, false
+	}
+	userId, _ := uid.(uint)
+
+	user, err := models.GetUserById(userId)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
+		return nil, false
+	}
+	return user, true
+}
+
+// 两步验证登录：提交挑战令牌和验证码（或恢复码）
+func HandleMfaLogin(c *gin.Context) {
+	var req MfaLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request. Please try again later.", nil)
+		return
+	}
+
+	claims, err := utils.ParseMfaToken(req.MfaToken)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Please log in to continue!", nil)
+		return
+	}
+
+	user, err := models.GetUserById(claims.Uid)
+	if err != nil || !user.TotpEnabled {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Please log in to continue!", nil)
+		return
+	}
+
+	if !checkSecondFactor(c, user, req.Code, http.StatusUnauthorized) {
+		return
+	}
+
+	// 挑战令牌只能完成一次登录
+	if err := models.ConsumeMfaToken(claims.ID, claims.ExpiresAt.Time); err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Please log in to continue!", nil)
+		return
+	}
+
+	perms, err := models.GetUserWithPermissions(user.ID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "get permissions error", nil)
+		return
+	}
+
+	loginResp, err := issueTokens(c, user, perms, true)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "generate token error", nil)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "success", loginResp)
+}
+
+// 生成待启用的 TOTP 密钥
+func SetupTotp(c *gin.Context) {
+	if rejectApiToken(c) {
+		return
+	}
+
+	user, ok := loadCurrentUser(c)
+	if !ok {
+		return
+	}
+	if user.TotpEnabled {
+		utils.ErrorResponse(c, http.StatusConflict, "two-factor authentication already enabled", nil)
+		return
+	}
+
+	secret, err := utils.GenerateTotpSecret()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "generate secret error", nil)
+		return
+	}
+	if err := models.SetPendingTotpSecret(user, secret); err != nil {
+		logger.Log.Errorf("save totp secret failed: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "setup fail", nil)
+		return
+	}
+
+	issuer := viper.GetString("mfa.issuer")
+	if issuer == "" {
+		issuer = "DevPlaza"
+	}
+	utils.SuccessResponse(c, http.StatusOK, "success", TotpSetupResponse{
+		Secret:     secret,
+		OtpauthUrl: utils.TotpURI(issuer, user.Email, secret),
+	})
+}
+
+// 验证首个验证码后启用两步验证，返回恢复码
+func EnableTotp(c *gin.Context) {
+	if rejectApiToken(c) {
+		return
+	}
+
+	var req TotpCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request", nil)
+		return
+	}
+
+	user, ok := loadCurrentUser(c)
+	if !ok {
+		return
+	}
+	if user.TotpEnabled {
+		utils.ErrorResponse(c, http.StatusConflict, "two-factor authentication already enabled", nil)
+		return
+	}
+	if user.TotpSecret == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "two-factor authentication not set up", nil)
+		return
+	}
+
+	step, valid := utils.ValidateTotp(user.TotpSecret, strings.TrimSpace(req.Code), time.Now())
+	if !valid {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid verification code", nil)
+		return
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "generate recovery codes error", nil)
+		return
+	}
+	if err := models.EnableTotp(user, step, hashes); err != nil {
+		logger.Log.Errorf("enable totp failed: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "enable fail", nil)
+		return
+	}
+
+	// 当前会话已证明持有验证器，刷新令牌后即可获得两步验证状态
+	if err := models.MarkSessionMfaVerified(c.GetString("sid")); err != nil {
+		logger.Log.Errorf("mark session mfa verified failed: %v", err)
+	}
+	// 其他设备上的会话可能是在开启前被盗用的，全部结束，之后需要通过两步验证重新登录
+	if err := models.RevokeOtherUserTokens(user.ID, c.GetString("sid")); err != nil {
+		logger.Log.Errorf("revoke other sessions failed: %v", err)
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "two-factor authentication enabled", RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// 关闭两步验证，需要验证码或恢复码
+func DisableTotp(c *gin.Context) {
+	if rejectApiToken(c) {
+		return
+	}
+
+	var req TotpCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request", nil)
+		return
+	}
+
+	user, ok := loadCurrentUser(c)
+	if !ok {
+		return
+	}
+	if !user.TotpEnabled {
+		utils.ErrorResponse(c, http.StatusBadRequest, "two-factor authentication not enabled", nil)
+		return
+	}
+	if !checkSecondFactor(c, user, req.Code, http.StatusBadRequest) {
+		return
+	}
+
+	if err := models.DisableTotp(user); err != nil {
+		logger.Log.Errorf("disable totp failed: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "disable fail", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "two-factor authentication disabled", nil)
+}
+
+// 重新生成恢复码，旧的全部作废
+func RegenerateRecoveryCodes(c *gin.Context) {
+	if rejectApiToken(c) {
+		return
+	}
+
+	var req TotpCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request", nil)
+		return
+	}
+
+	user, ok := loadCurrentUser(c)
+	if !ok {
+		return
+	}
+	if !user.TotpEnabled {
+		utils.ErrorResponse(c, http.StatusBadRequest, "two-factor authentication not enabled", nil)
+		return
+	}
+	if !checkSecondFactor(c, user, req.Code, http.StatusBadRequest) {
+		return
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "generate recovery codes error", nil)
+		return
+	}
+	if err := models.ReplaceRecoveryCodes(user.ID, hashes); err != nil {
+		logger.Log.Errorf("replace recovery codes failed: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "generate fail", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "success", RecoveryCodesResponse{RecoveryCodes: codes})
+}
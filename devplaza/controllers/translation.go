@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"devplaza/logger"
+	"devplaza/models"
+	"devplaza/policy"
+	"devplaza/utils"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// translationLanguageAvailable 修改语言时检查翻译组中是否已有该语言的内容，不可用时已写入响应
+func translationLanguageAvailable(c *gin.Context, contentType string, id uint, groupId *uint, language string) bool {
+	if groupId == nil {
+		return true
+	}
+	exists, err := models.TranslationLanguageExists(contentType, *groupId, language, id)
+	if err != nil {
+		logger.Log.Errorf("check %s %d translation language failed: %v", contentType, id, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to check translation", nil)
+		return false
+	}
+	if exists {
+		utils.ErrorResponse(c, http.StatusConflict, models.ErrTranslationExists.Error(), nil)
+		return false
+	}
+	return true
+}
+
+// listTranslations 同一翻译组中已发布的原文和译文
+func listTranslations(c *gin.Context, contentType string, groupId *uint) {
+	if groupId == nil {
+		utils.SuccessResponse(c, http.StatusOK, "success", []models.Translation{})
+		return
+	}
+	translations, err := models.ListTranslations(contentType, *groupId, true)
+	if err != nil {
+		logger.Log.Errorf("list %s %d translations failed: %v", contentType, *groupId, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to list translations", nil)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "success", translations)
+}
+
+// translationSource 读取原文的权限资源，原文不存在时返回 models.ErrInvalidTranslation
+func translationSource(contentType string, sourceId uint) (policy.Resource, error) {
+	switch contentType {
+	case policy.Blog:
+		var article models.Article
+		if err := article.Load(sourceId); err != nil {
+			return policy.Resource{}, models.ErrInvalidTranslation
+		}
+		return articleResource(&article), nil
+	case policy.Tutorial:
+		var tutorial models.Tutorial
+		tutorial.ID = sourceId
+		if err := tutorial.Load(); err != nil {
+			return policy.Resource{}, models.ErrInvalidTranslation
+		}
+		return tutorialResource(&tutorial), nil
+	}
+	return policy.Resource{}, models.ErrInvalidTranslation
+}
+
+// attachTranslation 将内容设为原文的译文；译文更新后再次调用可以标记为已与原文最新版本同步。
+// 同时需要修改译文和原文的权限，避免把内容挂到他人的原文下
+func attachTranslation(c *gin.Context, contentType string, id uint, resource policy.Resource) {
+	if !authorize(c, policy.Update, resource) {
+		return
+	}
+
+	var req AttachTranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid input data", nil)
+		return
+	}
+
+	source, err := translationSource(contentType, req.SourceId)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if !authorize(c, policy.Update, source) {
+		return
+	}
+
+	version, err := models.AttachTranslation(contentType, id, req.SourceId)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidTranslation):
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		case errors.Is(err, models.ErrTranslationExists), errors.Is(err, models.ErrTranslationInGroup):
+			utils.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+		default:
+			logger.Log.Errorf("attach %s %d translation to %d failed: %v", contentType, id, req.SourceId, err)
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to attach translation", nil)
+		}
+		return
+	}
+	recordAudit(c, AuditAttach, contentType, id, nil, gin.H{"source_id": req.SourceId, "source_version": version})
+
+	translations, err := models.ListTranslations(contentType, req.SourceId, false)
+	if err != nil {
+		logger.Log.Errorf("list %s %d translations failed: %v", contentType, req.SourceId, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to list translations", nil)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "success", translations)
+}
+
+// detachTranslation 将内容移出翻译组
+func detachTranslation(c *gin.Context, contentType string, id uint, groupId *uint, resource policy.Resource) {
+	if !authorize(c, policy.Update, resource) {
+		return
+	}
+	if groupId == nil {
+		utils.SuccessResponse(c, http.StatusOK, "success", nil)
+		return
+	}
+	if err := models.DetachTranslation(contentType, id, *groupId); err != nil {
+		logger.Log.Errorf("detach %s %d translation failed: %v", contentType, id, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to detach translation", nil)
+		return
+	}
+	recordAudit(c, AuditDetach, contentType, id, gin.H{"translation_group_id": *groupId}, nil)
+	utils.SuccessResponse(c, http.StatusOK, "success", nil)
+}
+
+func GetArticleTranslations(c *gin.Context) {
+	article, ok := loadArticle(c)
+	if !ok {
+		return
+	}
+	listTranslations(c, policy.Blog, article.TranslationGroupId)
+}
+
+func AttachArticleTranslation(c *gin.Context) {
+	article, ok := loadArticle(c)
+	if !ok {
+		return
+	}
+	attachTranslation(c, policy.Blog, article.ID, articleResource(article))
+}
+
+func DetachArticleTranslation(c *gin.Context) {
+	article, ok := loadArticle(c)
+	if !ok {
+		return
+	}
+	detachTranslation(c, policy.Blog, article.ID, article.TranslationGroupId, articleResource(article))
+}
+
+func GetTutorialTranslations(c *gin.Context) {
+	tutorial, ok := loadTutorial(c)
+	if !ok {
+		return
+	}
+	listTranslations(c, policy.Tutorial, tutorial.TranslationGroupId)
+}
+
+func AttachTutorialTranslation(c *gin.Context) {
+	tutorial, ok := loadTutorial(c)
+	if !ok {
+		return
+	}
+	attachTranslation(c, policy.Tutorial, tutorial.ID, tutorialResource(tutorial))
+}
+
+func DetachTutorialTranslation(c *gin.Context) {
+	tutorial, ok := loadTutorial(c)
+	if !ok {
+		return
+	}
+	detachTranslation(c, policy.Tutorial, tutorial.ID, tutorial.TranslationGroupId, tutorialResource(tutorial))
+}
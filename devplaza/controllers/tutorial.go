@@ -1,11 +1,13 @@
 package controllers
 
 import (
+	"devplaza/logger"
 	"devplaza/models"
+	"devplaza/policy"
 	"devplaza/utils"
+	"devplaza/workflow"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -26,6 +28,7 @@ func CreateTutorial(c *gin.Context) {
 		CoverImg:    req.CoverImg,
 		Tags:        req.Tags,
 		SourceLink:  req.SourceLink,
+		Language:    req.Language,
 	}
 
 	uid, ok := c.Get("uid")
@@ -47,8 +50,8 @@ func CreateTutorial(c *gin.Context) {
 		tutorial.DappId = &dapp.ID
 	}
 
-	// 创建数据库记录
-	if err := tutorial.Create(); err != nil {
+	// 创建数据库记录，同时记录第一个版本
+	if err := tutorial.SaveWithRevision(userId); err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
 		return
 	}
@@ -71,6 +74,14 @@ func GetTutorial(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid Tutorial", nil)
 		return
 	}
+	tutorial.TranslationOutdated = models.TranslationOutdated(policy.Tutorial, tutorial.ID, tutorial.TranslationGroupId, tutorial.TranslationSourceVersion)
+	if tutorial.SeriesId != nil {
+		prev, next, err := models.SeriesNeighbors(*tutorial.SeriesId, tutorial.SeriesPosition)
+		if err != nil {
+			logger.Log.Errorf("get tutorial %d series neighbors failed: %v", tutorial.ID, err)
+		}
+		tutorial.SeriesPrev, tutorial.SeriesNext = prev, next
+	}
 
 	utils.SuccessResponse(c, http.StatusOK, "success", tutorial)
 }
@@ -82,6 +93,8 @@ func QueryTutorials(c *gin.Context) {
 	dappId, _ := strconv.Atoi(c.DefaultQuery("dapp_id", "0"))
 	publishStatus, _ := strconv.Atoi(c.DefaultQuery("publish_status", "0"))
 	userId, _ := strconv.Atoi(c.Query("user_id"))
+	language := c.Query("language")
+	seriesId, _ := strconv.Atoi(c.Query("series_id"))
 
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "6"))
@@ -92,6 +105,8 @@ func QueryTutorials(c *gin.Context) {
 		DappId:        uint(dappId),
 		PublisherId:   userId,
 		PublishStatus: publishStatus,
+		Language:      language,
+		SeriesId:      uint(seriesId),
 		OrderDesc:     order == "desc",
 		Page:          page,
 		PageSize:      pageSize,
@@ -123,20 +138,12 @@ func DeleteTutorial(c *gin.Context) {
 	var tutorial models.Tutorial
 	tutorial.ID = uint(id)
 
-	if err = tutorial.GetByID(); err != nil {
+	if err = tutorial.Load(); err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid tutorial", nil)
 		return
 	}
 
-	uid, ok := c.Get("uid")
-	if !ok {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
-		return
-	}
-
-	userId, _ := uid.(uint)
-	if tutorial.PublisherId != userId {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "not author", nil)
+	if !authorize(c, policy.Delete, tutorialResource(&tutorial)) {
 		return
 	}
 
@@ -144,6 +151,7 @@ func DeleteTutorial(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete tutorial", nil)
 		return
 	}
+	recordAudit(c, AuditDelete, policy.Tutorial, tutorial.ID, tutorial, nil)
 	utils.SuccessResponse(c, http.StatusOK, "delete success", nil)
 }
 
@@ -164,20 +172,12 @@ func UpdateTutorial(c *gin.Context) {
 	var tutorial models.Tutorial
 	tutorial.ID = uint(id)
 
-	if err = tutorial.GetByID(); err != nil {
+	if err = tutorial.Load(); err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid tutorial", nil)
 		return
 	}
 
-	uid, ok := c.Get("uid")
-	if !ok {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
-		return
-	}
-
-	userId, _ := uid.(uint)
-	if tutorial.PublisherId != userId {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "not author", nil)
+	if !authorize(c, policy.Update, tutorialResource(&tutorial)) {
 		return
 	}
 
@@ -198,12 +198,22 @@ func UpdateTutorial(c *gin.Context) {
 	tutorial.SourceLink = req.SourceLink
 	tutorial.CoverImg = req.CoverImg
 	tutorial.Tags = req.Tags
-	tutorial.PublishStatus = 1 // 编辑更新后需要重新审核
+	if req.Language != "" && req.Language != tutorial.Language {
+		if !translationLanguageAvailable(c, policy.Tutorial, tutorial.ID, tutorial.TranslationGroupId, req.Language) {
+			return
+		}
+		tutorial.Language = req.Language
+	}
+	from := tutorial.PublishStatus
+	tutorial.PublishStatus = uint(workflow.AfterEdit(workflow.Status(from))) // 已发布、被驳回的教程编辑更新后重新进入审核
 
-	if err := tutorial.Update(); err != nil {
+	uid, _ := c.Get("uid")
+	userId, _ := uid.(uint)
+	if err := tutorial.SaveWithRevision(userId); err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update tutorial", nil)
 		return
 	}
+	recordEditTransition(c, policy.Tutorial, tutorial.ID, from, tutorial.PublishStatus)
 	utils.SuccessResponse(c, http.StatusOK, "success", tutorial)
 }
 
@@ -224,23 +234,85 @@ func UpdateTutorialPublishStatus(c *gin.Context) {
 	var tutorial models.Tutorial
 	tutorial.ID = uint(id)
 
-	if err = tutorial.GetByID(); err != nil {
+	if err = tutorial.Load(); err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid tutorial", nil)
 		return
 	}
 
-	if tutorial.PublishStatus != 1 && tutorial.PublishStatus != 2 {
-		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid status", nil)
+	// 作者可以提交、撤回、下架自己的教程；审核员（全局或该教程所属 Dapp、分类）可以通过、驳回
+	publishAt, ok := requestPublishAt(c, req.PublishAt, tutorial.PublishAt)
+	if !ok {
+		return
+	}
+	if !changePublishStatus(c, &tutorial, policy.Tutorial, tutorial.ID, tutorialResource(&tutorial), tutorial.PublishStatus, req.PublishStatus, req.Comment, publishAt) {
 		return
 	}
 
-	// TODO: 2 -> 1 ?
-	now := time.Now()
-	tutorial.PublishStatus = req.PublishStatus
-	tutorial.PublishTime = &now
+	// 重新读取变更后的状态和发布时间
+	if err := tutorial.Load(); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get tutorial", nil)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "success", tutorial)
+}
 
-	if err := tutorial.Update(); err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update tutorial", nil)
+func GetTutorialTransitions(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID", nil)
+		return
+	}
+
+	var tutorial models.Tutorial
+	tutorial.ID = uint(id)
+
+	if err = tutorial.Load(); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid tutorial", nil)
+		return
+	}
+
+	listPublishTransitions(c, policy.Tutorial, tutorial.ID, tutorialResource(&tutorial), tutorial.PublishStatus)
+}
+
+// ScheduleTutorial 设置定时发布时间
+func ScheduleTutorial(c *gin.Context) {
+	tutorial, ok := loadTutorial(c)
+	if !ok {
+		return
+	}
+
+	var req ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid input data", nil)
+		return
+	}
+	publishAt, err := utils.ParseTime(req.PublishAt)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid publish_at", nil)
+		return
+	}
+
+	if !scheduleContent(c, tutorial, policy.Tutorial, tutorial.ID, tutorialResource(tutorial), tutorial.PublishStatus, publishAt) {
+		return
+	}
+	tutorial.PublishAt = &publishAt
+	utils.SuccessResponse(c, http.StatusOK, "success", tutorial)
+}
+
+// CancelTutorialSchedule 取消定时发布
+func CancelTutorialSchedule(c *gin.Context) {
+	tutorial, ok := loadTutorial(c)
+	if !ok {
+		return
+	}
+
+	if !cancelSchedule(c, tutorial, policy.Tutorial, tutorial.ID, tutorialResource(tutorial), tutorial.PublishStatus, tutorial.PublishAt) {
+		return
+	}
+
+	if err := tutorial.Load(); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get tutorial", nil)
 		return
 	}
 	utils.SuccessResponse(c, http.StatusOK, "success", tutorial)
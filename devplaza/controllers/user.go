@@ -3,11 +3,16 @@ package controllers
 import (
 	"devplaza/logger"
 	"devplaza/models"
+	"devplaza/policy"
 	"devplaza/utils"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func GetUser(c *gin.Context) {
@@ -24,7 +29,14 @@ func GetUser(c *gin.Context) {
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "success", user)
+	contributions, err := models.ListUserContributions(user.ID)
+	if err != nil {
+		logger.Log.Errorf("list user %d contributions failed: %v", user.ID, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get user", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "success", GetUserResponse{User: user, Contributions: contributions})
 }
 
 func UpdateUser(c *gin.Context) {
@@ -48,19 +60,27 @@ func UpdateUser(c *gin.Context) {
 		return
 	}
 
-	uid, ok := c.Get("uid")
-	if !ok {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
+	if !authorize(c, policy.Update, policy.Resource{Type: policy.User, OwnerId: user.ID}) {
 		return
 	}
-	userId, _ := uid.(uint)
 
-	if user.ID != userId {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "permission denied.", nil)
-		return
+	before := *user
+
+	// 修改邮箱后需要重新验证，否则可以改成他人尚未注册的邮箱，等对方用第三方账号登录时被关联过来
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	emailChanged := email != strings.ToLower(user.Email)
+	if emailChanged {
+		existing := models.User{Email: email}
+		if err := models.GetUserByEmail(&existing); err == nil {
+			utils.ErrorResponse(c, http.StatusConflict, "email already in use", nil)
+			return
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Log.Errorf("get user by email failed: %v", err)
+			utils.ErrorResponse(c, http.StatusInternalServerError, "update fail", nil)
+			return
+		}
 	}
 
-	user.Email = req.Email
 	user.Username = req.Username
 	user.Avatar = req.Avatar
 	user.Github = req.Github
@@ -69,6 +89,19 @@ func UpdateUser(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusUnauthorized, "update fail", nil)
 		return
 	}
+	if emailChanged {
+		if err := models.ChangeUserEmail(user, email); err != nil {
+			logger.Log.Errorf("change user %d email failed: %v", user.ID, err)
+			utils.ErrorResponse(c, http.StatusInternalServerError, "update fail", nil)
+			return
+		}
+		err = sendEmailToken(user, models.EmailTokenVerify, 24*time.Hour, "/verify-email",
+			"Verify your DevPlaza email", "Please confirm your new email address by opening the link below:")
+		if err != nil {
+			logger.Log.Errorf("send verify email failed: %v", err)
+		}
+	}
+	recordAudit(c, AuditUpdate, policy.User, user.ID, before, user)
 	utils.SuccessResponse(c, http.StatusOK, "success update", user)
 }
 
@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"devplaza/logger"
+	"devplaza/models"
+	"devplaza/policy"
+	"devplaza/utils"
+	"devplaza/workflow"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// publishActors 当前用户对内容可使用的状态变更角色：有编辑权限的作者本人，或该内容的审核员。
+// 贡献者只能修改内容，不能提交、下架或归档
+func publishActors(subject policy.Subject, resource policy.Resource) []workflow.Actor {
+	var actors []workflow.Actor
+	if resource.IsOwner(subject.UserId) && policy.Can(subject, policy.Update, resource) {
+		actors = append(actors, workflow.Author)
+	}
+	if policy.Can(subject, policy.Review, resource) {
+		actors = append(actors, workflow.Reviewer)
+	}
+	return actors
+}
+
+// requestPublishAt 解析请求中的定时发布时间，未填写时沿用内容原有的时间，失败时已写入响应
+func requestPublishAt(c *gin.Context, value string, current *time.Time) (*time.Time, bool) {
+	if value == "" {
+		return current, true
+	}
+	publishAt, err := utils.ParseTime(value)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid publish_at", nil)
+		return nil, false
+	}
+	return &publishAt, true
+}
+
+// changePublishStatus 按发布状态机变更内容状态并记录变更，失败时已写入响应。
+// content 为已加载的内容模型指针；publishAt 为定时发布时间，审核通过时若晚于当前时间则进入定时发布
+func changePublishStatus(c *gin.Context, content interface{}, contentType string, id uint, resource policy.Resource, from, to uint, comment string, publishAt *time.Time) bool {
+	subject, ok := currentSubject(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
+		return false
+	}
+	actors := publishActors(subject, resource)
+	if len(actors) == 0 {
+		utils.ErrorResponse(c, http.StatusForbidden, "permission denied", nil)
+		return false
+	}
+
+	now := time.Now()
+	if workflow.Status(to) == workflow.Published && publishAt != nil && publishAt.After(now) {
+		to = uint(workflow.Scheduled)
+	}
+	if workflow.Status(to) == workflow.Scheduled && (publishAt == nil || !publishAt.After(now)) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "publish_at must be in the future", nil)
+		return false
+	}
+
+	if err := workflow.Check(workflow.Status(from), workflow.Status(to), comment, actors...); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return false
+	}
+
+	updates := map[string]interface{}{"publish_at": publishAt}
+	if workflow.Status(to) == workflow.Published {
+		updates["publish_time"] = now
+		updates["publish_at"] = nil
+	}
+
+	transition := models.PublishTransition{
+		ContentType: contentType,
+		ContentId:   id,
+		FromStatus:  from,
+		ToStatus:    to,
+		ActorId:     subject.UserId,
+		Comment:     comment,
+	}
+	if err := models.ChangePublishStatus(content, &transition, updates); err != nil {
+		if errors.Is(err, models.ErrPublishStatusChanged) {
+			utils.ErrorResponse(c, http.StatusConflict, "status changed, please reload", nil)
+			return false
+		}
+		logger.Log.Errorf("change %s %d publish status failed: %v", contentType, id, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update status", nil)
+		return false
+	}
+
+	recordAudit(c, AuditPublishStatus, contentType, id,
+		gin.H{"publish_status": from},
+		gin.H{"publish_status": to, "publish_at": updates["publish_at"], "comment": comment})
+	return true
+}
+
+// scheduleContent 设置定时发布时间：审核通过前为作者期望的发布时间，审核通过后为实际发布的时间。
+// 审核通过后只有审核员能直接修改时间，作者修改时内容退回待审核。失败时已写入响应
+func scheduleContent(c *gin.Context, content interface{}, contentType string, id uint, resource policy.Resource, status uint, publishAt time.Time) bool {
+	subject, ok := currentSubject(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
+		return false
+	}
+	actors := publishActors(subject, resource)
+	if len(actors) == 0 {
+		utils.ErrorResponse(c, http.StatusForbidden, "permission denied", nil)
+		return false
+	}
+
+	if s := workflow.Status(status); s == workflow.Published || s == workflow.Archived {
+		utils.ErrorResponse(c, http.StatusBadRequest, "content can not be scheduled in status "+s.String(), nil)
+		return false
+	}
+	if !publishAt.After(time.Now()) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "publish_at must be in the future", nil)
+		return false
+	}
+
+	if workflow.Status(status) == workflow.Scheduled && !slices.Contains(actors, workflow.Reviewer) {
+		return changePublishStatus(c, content, contentType, id, resource, status, uint(workflow.PendingReview), "", &publishAt)
+	}
+	return setPublishAt(c, content, contentType, id, status, &publishAt)
+}
+
+// cancelSchedule 取消定时发布。已审核通过等待发布的内容退回待审核，失败时已写入响应
+func cancelSchedule(c *gin.Context, content interface{}, contentType string, id uint, resource policy.Resource, status uint, publishAt *time.Time) bool {
+	if publishAt == nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "content is not scheduled", nil)
+		return false
+	}
+	if workflow.Status(status) == workflow.Scheduled {
+		return changePublishStatus(c, content, contentType, id, resource, status, uint(workflow.PendingReview), "", nil)
+	}
+
+	subject, ok := currentSubject(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
+		return false
+	}
+	if len(publishActors(subject, resource)) == 0 {
+		utils.ErrorResponse(c, http.StatusForbidden, "permission denied", nil)
+		return false
+	}
+	return setPublishAt(c, content, contentType, id, status, nil)
+}
+
+func setPublishAt(c *gin.Context, content interface{}, contentType string, id uint, status uint, publishAt *time.Time) bool {
+	if err := models.SetPublishAt(content, status, publishAt); err != nil {
+		if errors.Is(err, models.ErrPublishStatusChanged) {
+			utils.ErrorResponse(c, http.StatusConflict, "status changed, please reload", nil)
+			return false
+		}
+		logger.Log.Errorf("set %s %d publish_at failed: %v", contentType, id, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update schedule", nil)
+		return false
+	}
+	recordAudit(c, AuditSchedule, contentType, id, nil, gin.H{"publish_at": publishAt})
+	return true
+}
+
+// recordEditTransition 作者编辑内容导致状态变化（如重新进入审核）时记录变更，失败只记日志
+func recordEditTransition(c *gin.Context, contentType string, id uint, from, to uint) {
+	if from == to {
+		return
+	}
+	uid, _ := c.Get("uid")
+	actorId, _ := uid.(uint)
+	transition := models.PublishTransition{
+		ContentType: contentType,
+		ContentId:   id,
+		FromStatus:  from,
+		ToStatus:    to,
+		ActorId:     actorId,
+	}
+	if err := transition.Create(); err != nil {
+		logger.Log.Errorf("record %s %d publish transition failed: %v", contentType, id, err)
+	}
+}
+
+// listPublishTransitions 返回内容的状态变更记录和当前用户可执行的状态变更，仅作者和审核员可见
+func listPublishTransitions(c *gin.Context, contentType string, id uint, resource policy.Resource, status uint) {
+	subject, ok := currentSubject(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+	actors := publishActors(subject, resource)
+	if len(actors) == 0 {
+		utils.ErrorResponse(c, http.StatusForbidden, "permission denied", nil)
+		return
+	}
+
+	transitions, err := models.ListPublishTransitions(contentType, id)
+	if err != nil {
+		logger.Log.Errorf("list %s %d publish transitions failed: %v", contentType, id, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to list transitions", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "success", PublishTransitionsResponse{
+		PublishStatus: status,
+		Next:          workflow.Next(workflow.Status(status), actors...),
+		Transitions:   transitions,
+	})
+}
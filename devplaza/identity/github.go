@@ -0,0 +1,89 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	githubTokenURL  = "https://github.com/login/oauth/access_token"
+	githubUserURL   = "https://api.github.com/user"
+	githubEmailsURL = "https://api.github.com/user/emails"
+)
+
+// githubProvider 直接对接 GitHub OAuth App
+type githubProvider struct{}
+
+func init() {
+	Register(githubProvider{})
+}
+
+func (githubProvider) Name() string {
+	return "github"
+}
+
+func (githubProvider) Exchange(ctx context.Context, code, redirectURI string) (*Identity, error) {
+	form := url.Values{}
+	form.Set("client_id", viper.GetString("github.clientId"))
+	form.Set("client_secret", viper.GetString("github.clientSecret"))
+	form.Set("code", code)
+	if redirectURI != "" {
+		form.Set("redirect_uri", redirectURI)
+	}
+
+	var tokenResp struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := postForm(ctx, githubTokenURL, form, &tokenResp); err != nil {
+		return nil, err
+	}
+	if tokenResp.AccessToken == "" {
+		return nil, errors.New("github: " + tokenResp.Error + " " + tokenResp.ErrorDescription)
+	}
+
+	var user struct {
+		ID        int64  `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err := getJSON(ctx, githubUserURL, tokenResp.AccessToken, &user); err != nil {
+		return nil, err
+	}
+
+	// 公开资料里的邮箱不一定经过验证，以邮箱列表中的主邮箱为准
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, githubEmailsURL, tokenResp.AccessToken, &emails); err != nil {
+		return nil, err
+	}
+
+	ident := &Identity{
+		Provider: "github",
+		Subject:  strconv.FormatInt(user.ID, 10),
+		Username: user.Name,
+		Avatar:   user.AvatarURL,
+		Github:   user.Login,
+	}
+	if ident.Username == "" {
+		ident.Username = user.Login
+	}
+	for _, e := range emails {
+		if e.Primary {
+			ident.Email = e.Email
+			ident.EmailVerified = e.Verified
+			break
+		}
+	}
+
+	return ident, nil
+}
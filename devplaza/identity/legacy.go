@@ -0,0 +1,115 @@
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"devplaza/utils"
+
+	"github.com/spf13/viper"
+)
+
+type AccessTokenRequest struct {
+	ClientId     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	Code         string `json:"code"`
+}
+
+// 定义响应的结构体
+type AccessTokenResponse struct {
+	Status int `json:"status"`
+	Code   int `json:"code"`
+	Data   struct {
+		Token string `json:"token"`
+	} `json:"data"`
+	Time    int64  `json:"time"`
+	Message string `json:"message"`
+	ID      string `json:"id"`
+}
+
+// 定义数据部分的结构体
+type UserData struct {
+	Uid      uint   `json:"uid"`
+	Avatar   string `json:"avatar"`
+	UserName string `json:"user_name"`
+	Email    string `json:"email"`
+	Github   string `json:"github"`
+}
+
+// 定义顶层响应的结构体
+type GetUserResponse struct {
+	ID      string   `json:"id"`
+	Status  int      `json:"status"`
+	Code    int      `json:"code"`
+	Data    UserData `json:"data"`
+	Time    int64    `json:"time"`
+	Message string   `json:"message"`
+}
+
+// legacyProvider 原有的 OAuth 服务，用户以 User.Uid 标识
+type legacyProvider struct{}
+
+func init() {
+	Register(legacyProvider{})
+}
+
+func (legacyProvider) Name() string {
+	return DefaultProvider
+}
+
+func (legacyProvider) Exchange(ctx context.Context, code, redirectURI string) (*Identity, error) {
+	var accessRequest AccessTokenRequest
+	accessRequest.ClientId = viper.GetString("oauth.clientId")
+	accessRequest.ClientSecret = viper.GetString("oauth.clientSecret")
+	accessRequest.Code = code
+
+	var reqArgs utils.HTTPRequestParams
+	reqArgs.URL = viper.GetString("oauth.accessApi")
+	reqArgs.Method = "POST"
+	reqArgs.Body = accessRequest
+
+	result, err := utils.SendHTTPRequest(reqArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokenResp AccessTokenResponse
+	if err := json.Unmarshal([]byte(result), &tokenResp); err != nil {
+		return nil, err
+	}
+	if tokenResp.Status != 200 {
+		return nil, fmt.Errorf("access token error: %v", tokenResp)
+	}
+
+	reqArgs.URL = viper.GetString("oauth.getUser")
+	reqArgs.Method = "GET"
+	reqArgs.Body = nil
+	reqArgs.Headers = map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", tokenResp.Data.Token),
+	}
+
+	userResult, err := utils.SendHTTPRequest(reqArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp GetUserResponse
+	if err := json.Unmarshal([]byte(userResult), &resp); err != nil {
+		return nil, err
+	}
+	if resp.Status != 200 {
+		return nil, fmt.Errorf("get user error: %s", resp.Message)
+	}
+
+	return &Identity{
+		Provider:      DefaultProvider,
+		Subject:       strconv.FormatUint(uint64(resp.Data.Uid), 10),
+		Email:         resp.Data.Email,
+		EmailVerified: true,
+		Username:      resp.Data.UserName,
+		Avatar:        resp.Data.Avatar,
+		Github:        resp.Data.Github,
+	}, nil
+}
@@ -0,0 +1,159 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"devplaza/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/spf13/viper"
+)
+
+// oidcDiscovery OpenID Provider 元数据
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+type idTokenClaims struct {
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+	Picture           string `json:"picture"`
+	jwt.RegisteredClaims
+}
+
+// oidcProvider 通用 OpenID Connect 提供方（discovery + ID Token 校验）
+type oidcProvider struct {
+	mu          sync.Mutex
+	issuer      string
+	discovery   *oidcDiscovery
+	discoveryAt time.Time
+	jwks        utils.JWKSet
+}
+
+func init() {
+	Register(&oidcProvider{})
+}
+
+func (p *oidcProvider) Name() string {
+	return "oidc"
+}
+
+// metadata 获取并缓存 discovery 文档，issuer 变更或缓存过期后重新拉取
+func (p *oidcProvider) metadata(ctx context.Context) (*oidcDiscovery, error) {
+	issuer := strings.TrimSuffix(viper.GetString("oidc.issuer"), "/")
+	if issuer == "" {
+		return nil, errors.New("oidc: issuer not configured")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil && p.issuer == issuer && time.Since(p.discoveryAt) < time.Hour {
+		return p.discovery, nil
+	}
+
+	var d oidcDiscovery
+	if err := getJSON(ctx, issuer+"/.well-known/openid-configuration", "", &d); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(d.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("oidc: issuer mismatch: %s", d.Issuer)
+	}
+
+	p.issuer = issuer
+	p.discovery = &d
+	p.discoveryAt = time.Now()
+	p.jwks = utils.JWKSet{}
+	return &d, nil
+}
+
+// key 按 kid 查找签名公钥，找不到时刷新 JWKS（提供方可能已轮换密钥）
+func (p *oidcProvider) key(ctx context.Context, d *oidcDiscovery, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	k, ok := p.jwks.Find(kid)
+	if !ok {
+		var set utils.JWKSet
+		if err := getJSON(ctx, d.JwksURI, "", &set); err != nil {
+			return nil, err
+		}
+		p.jwks = set
+		if k, ok = p.jwks.Find(kid); !ok {
+			return nil, fmt.Errorf("oidc: unknown key id %q", kid)
+		}
+	}
+	return k.PublicKey()
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code, redirectURI string) (*Identity, error) {
+	d, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	clientId := viper.GetString("oidc.clientId")
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("client_id", clientId)
+	form.Set("client_secret", viper.GetString("oidc.clientSecret"))
+	if redirectURI != "" {
+		form.Set("redirect_uri", redirectURI)
+	}
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+		IDToken     string `json:"id_token"`
+		Error       string `json:"error"`
+	}
+	if err := postForm(ctx, d.TokenEndpoint, form, &tokenResp); err != nil {
+		return nil, err
+	}
+	if tokenResp.IDToken == "" {
+		return nil, errors.New("oidc: missing id_token " + tokenResp.Error)
+	}
+
+	var claims idTokenClaims
+	_, err = jwt.ParseWithClaims(tokenResp.IDToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, d, kid)
+	},
+		jwt.WithIssuer(d.Issuer),
+		jwt.WithAudience(clientId),
+		jwt.WithExpirationRequired(),
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "ES256", "ES384", "EdDSA"}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: invalid id_token: %w", err)
+	}
+
+	ident := &Identity{
+		Provider:      "oidc",
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Username:      claims.Name,
+		Avatar:        claims.Picture,
+	}
+	if ident.Username == "" {
+		ident.Username = claims.PreferredUsername
+	}
+	if ident.Subject == "" {
+		return nil, errors.New("oidc: missing subject")
+	}
+
+	return ident, nil
+}
@@ -0,0 +1,56 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// DefaultProvider 未指定 provider 时使用原有的 OAuth 服务
+const DefaultProvider = "oauth"
+
+// Identity 身份提供方返回的用户信息
+type Identity struct {
+	Provider      string
+	Subject       string // 用户在该提供方下的唯一标识
+	Email         string
+	EmailVerified bool
+	Username      string
+	Avatar        string
+	Github        string
+	Address       string // 钱包地址
+}
+
+// IdentityProvider 第三方身份提供方
+type IdentityProvider interface {
+	Name() string
+	// Exchange 使用授权码换取用户身份
+	Exchange(ctx context.Context, code, redirectURI string) (*Identity, error)
+}
+
+var (
+	mu        sync.RWMutex
+	providers = map[string]IdentityProvider{}
+)
+
+// Register 注册身份提供方，同名覆盖
+func Register(p IdentityProvider) {
+	mu.Lock()
+	defer mu.Unlock()
+	providers[p.Name()] = p
+}
+
+// Get 按名称获取身份提供方，name 为空时返回默认提供方
+func Get(name string) (IdentityProvider, error) {
+	if name == "" {
+		name = DefaultProvider
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	p, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown identity provider: %s", name)
+	}
+	return p, nil
+}
@@ -0,0 +1,84 @@
+package mailer
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+	"sync"
+
+	"devplaza/logger"
+
+	"github.com/spf13/viper"
+)
+
+// Mailer 邮件发送接口，本地开发可替换为 LogMailer
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// SMTPMailer 通过 SMTP 发送纯文本邮件
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", m.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(body)
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	addr := fmt.Sprintf("%s:%d", m.Host, m.Port)
+	return smtp.SendMail(addr, auth, m.From, []string{to}, []byte(msg.String()))
+}
+
+// LogMailer 只把邮件写入日志，未配置 SMTP 时使用
+type LogMailer struct{}
+
+func (LogMailer) Send(to, subject, body string) error {
+	logger.Log.Infof("mail to=%s subject=%q\n%s", to, subject, body)
+	return nil
+}
+
+var (
+	mu      sync.Mutex
+	current Mailer
+)
+
+// Default 返回当前使用的 Mailer，根据 smtp 配置懒加载
+func Default() Mailer {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if current == nil {
+		if host := viper.GetString("smtp.host"); host != "" {
+			current = &SMTPMailer{
+				Host:     host,
+				Port:     viper.GetInt("smtp.port"),
+				Username: viper.GetString("smtp.username"),
+				Password: viper.GetString("smtp.password"),
+				From:     viper.GetString("smtp.from"),
+			}
+		} else {
+			current = LogMailer{}
+		}
+	}
+	return current
+}
+
+// SetMailer 替换默认 Mailer（测试或本地调试用）
+func SetMailer(m Mailer) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = m
+}
@@ -0,0 +1,150 @@
+// Package markdown 在服务端处理博客、教程的 Markdown 正文：
+// 渲染为过滤后的 HTML，并提取目录、字数、阅读时间、外部链接和图片
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/russross/blackfriday/v2"
+)
+
+// 阅读速度：每分钟中文字数、英文单词数
+const (
+	cjkPerMinute   = 300
+	wordsPerMinute = 200
+)
+
+const extensions = blackfriday.CommonExtensions | blackfriday.AutoHeadingIDs
+
+// Heading 目录中的一个标题，Anchor 与 HTML 中标题的 id 一致
+type Heading struct {
+	Level  int    `json:"level"`
+	Text   string `json:"text"`
+	Anchor string `json:"anchor"`
+}
+
+// Result 处理后的正文
+type Result struct {
+	Html        string    // 过滤危险标签和属性后的 HTML
+	Toc         []Heading // 按出现顺序排列的标题
+	WordCount   int       // 中日韩文字按字计数，其他文字按单词计数
+	ReadingTime int       // 预计阅读分钟数，有正文时至少为 1
+	Links       []string  // 去重后的外部链接（http、https）
+	Images      []string  // 去重后的图片地址
+}
+
+// Process 处理 Markdown 正文
+func Process(source string) Result {
+	var result Result
+	if strings.TrimSpace(source) == "" {
+		return result
+	}
+
+	parser := blackfriday.New(blackfriday.WithExtensions(extensions))
+	ast := parser.Parse([]byte(source))
+
+	var text strings.Builder
+	anchors := make(map[string]bool)
+	ast.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if !entering {
+			return blackfriday.GoToNext
+		}
+		switch node.Type {
+		case blackfriday.Heading:
+			if node.IsTitleblock {
+				break
+			}
+			heading := Heading{Level: node.Level, Text: plainText(node)}
+			anchor := node.HeadingID
+			if anchor == "" {
+				anchor = blackfriday.SanitizedAnchorName(heading.Text)
+			}
+			heading.Anchor = uniqueAnchor(anchors, anchor)
+			node.HeadingID = heading.Anchor
+			result.Toc = append(result.Toc, heading)
+		case blackfriday.Text, blackfriday.Code, blackfriday.CodeBlock:
+			text.Write(node.Literal)
+			text.WriteByte(' ')
+		}
+		return blackfriday.GoToNext
+	})
+
+	renderer := blackfriday.NewHTMLRenderer(blackfriday.HTMLRendererParameters{Flags: blackfriday.CommonHTMLFlags})
+	var buf bytes.Buffer
+	renderer.RenderHeader(&buf, ast)
+	ast.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		return renderer.RenderNode(&buf, node, entering)
+	})
+	renderer.RenderFooter(&buf, ast)
+
+	sanitized := sanitize(buf.String())
+	result.Html = sanitized.html
+	result.Links = sanitized.links
+	result.Images = sanitized.images
+
+	cjk, words := countWords(text.String())
+	result.WordCount = cjk + words
+	result.ReadingTime = readingTime(cjk, words)
+	return result
+}
+
+// plainText 标题中的纯文本
+func plainText(node *blackfriday.Node) string {
+	var b strings.Builder
+	node.Walk(func(n *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if entering && (n.Type == blackfriday.Text || n.Type == blackfriday.Code) {
+			b.Write(n.Literal)
+		}
+		return blackfriday.GoToNext
+	})
+	return strings.TrimSpace(b.String())
+}
+
+// uniqueAnchor 同名标题依次加上 -1、-2 等后缀
+func uniqueAnchor(seen map[string]bool, anchor string) string {
+	if anchor == "" {
+		anchor = "section"
+	}
+	unique := anchor
+	for i := 1; seen[unique]; i++ {
+		unique = fmt.Sprintf("%s-%d", anchor, i)
+	}
+	seen[unique] = true
+	return unique
+}
+
+// countWords 统计中日韩文字数和其他文字的单词数
+func countWords(s string) (cjk, words int) {
+	inWord := false
+	for _, r := range s {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			cjk++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				words++
+			}
+			inWord = true
+		case r == '\'' || r == '-' || r == '_':
+			// 单词内的连字符、撇号不拆分单词
+		default:
+			inWord = false
+		}
+	}
+	return cjk, words
+}
+
+func readingTime(cjk, words int) int {
+	if cjk+words == 0 {
+		return 0
+	}
+	minutes := float64(cjk)/cjkPerMinute + float64(words)/wordsPerMinute
+	if m := int(minutes + 0.999999); m > 1 {
+		return m
+	}
+	return 1
+}
@@ -0,0 +1,139 @@
+package markdown
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProcessToc(t *testing.T) {
+	src := "# 简介\n\n正文\n\n## Install `cli`\n\n### Install cli\n\n## 简介\n"
+	got := Process(src).Toc
+	want := []Heading{
+		{1, "简介", "简介"},
+		{2, "Install cli", "install-cli"},
+		{3, "Install cli", "install-cli-1"},
+		{2, "简介", "简介-1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Toc = %+v, want %+v", got, want)
+	}
+
+	html := Process(src).Html
+	for _, h := range want {
+		if !strings.Contains(html, `id="`+h.Anchor+`"`) {
+			t.Errorf("html missing anchor %q: %s", h.Anchor, html)
+		}
+	}
+}
+
+func TestProcessSanitize(t *testing.T) {
+	cases := []struct {
+		name, src string
+		contains  []string
+		excludes  []string
+	}{
+		{
+			name:     "script",
+			src:      "hello <script>alert(1)</script> world",
+			contains: []string{"hello", "world"},
+			excludes: []string{"script", "alert"},
+		},
+		{
+			name:     "event handler",
+			src:      `<div onclick="alert(1)">x</div>`,
+			contains: []string{"<div>x</div>"},
+			excludes: []string{"onclick"},
+		},
+		{
+			name:     "javascript link",
+			src:      "[a](javascript:alert(1)) <a href=\"java&#x09;script:alert(1)\">b</a>",
+			excludes: []string{"javascript", "href"},
+		},
+		{
+			name:     "data image",
+			src:      "![x](data:image/svg+xml;base64,PHN2Zz4=)",
+			excludes: []string{"<img", "data:"},
+		},
+		{
+			name:     "unknown tag keeps content",
+			src:      "<custom>kept <b>bold</b></custom>",
+			contains: []string{"kept <b>bold</b>"},
+			excludes: []string{"custom"},
+		},
+		{
+			name:     "iframe and style",
+			src:      "<iframe src=\"https://evil.example\"></iframe><style>p{}</style>text",
+			contains: []string{"text"},
+			excludes: []string{"iframe", "style", "evil"},
+		},
+		{
+			name:     "code block language",
+			src:      "```go\nfmt.Println(\"<b>\")\n```",
+			contains: []string{`<code class="language-go">`, "&lt;b&gt;"},
+		},
+		{
+			name:     "external link",
+			src:      "[site](https://example.com)",
+			contains: []string{`href="https://example.com"`, `rel="nofollow noopener noreferrer"`},
+		},
+		{
+			name:     "comment",
+			src:      "a <!-- secret --> b",
+			excludes: []string{"secret"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			html := Process(tc.src).Html
+			for _, s := range tc.contains {
+				if !strings.Contains(html, s) {
+					t.Errorf("html %q should contain %q", html, s)
+				}
+			}
+			for _, s := range tc.excludes {
+				if strings.Contains(html, s) {
+					t.Errorf("html %q should not contain %q", html, s)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessLinksAndImages(t *testing.T) {
+	src := "[a](https://a.example/x) [b](/blogs/1) [c](#intro) [a again](https://a.example/x)\n\n" +
+		"<a href=\"http://b.example\">b</a> [mail](mailto:hi@example.com)\n\n" +
+		"![logo](https://cdn.example/logo.png) ![local](/img/1.png) <img src=\"https://cdn.example/logo.png\">"
+	r := Process(src)
+
+	wantLinks := []string{"https://a.example/x", "http://b.example"}
+	if !reflect.DeepEqual(r.Links, wantLinks) {
+		t.Errorf("Links = %v, want %v", r.Links, wantLinks)
+	}
+	wantImages := []string{"https://cdn.example/logo.png", "/img/1.png"}
+	if !reflect.DeepEqual(r.Images, wantImages) {
+		t.Errorf("Images = %v, want %v", r.Images, wantImages)
+	}
+}
+
+func TestProcessWordCount(t *testing.T) {
+	cases := []struct {
+		src         string
+		words, time int
+	}{
+		{"", 0, 0},
+		{"   \n", 0, 0},
+		{"Hello, world! It's a well-known example.", 6, 1},
+		{"以太坊开发者", 6, 1},
+		{"使用 Go 开发 dapp", 6, 1},
+		{strings.Repeat("字", 600) + " " + strings.Repeat("word ", 200), 800, 3},
+	}
+	for _, tc := range cases {
+		r := Process(tc.src)
+		if r.WordCount != tc.words || r.ReadingTime != tc.time {
+			t.Errorf("Process(%.20q) = %d words, %d min; want %d words, %d min",
+				tc.src, r.WordCount, r.ReadingTime, tc.words, tc.time)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package markdown
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowedAttrs 允许的标签及其属性，其他标签去掉但保留内容
+var allowedAttrs = map[atom.Atom][]string{
+	atom.P: nil, atom.Br: nil, atom.Hr: nil, atom.Div: nil, atom.Span: nil,
+	atom.H1: {"id"}, atom.H2: {"id"}, atom.H3: {"id"}, atom.H4: {"id"}, atom.H5: {"id"}, atom.H6: {"id"},
+	atom.Em: nil, atom.Strong: nil, atom.B: nil, atom.I: nil, atom.Del: nil, atom.S: nil,
+	atom.Sup: nil, atom.Sub: nil, atom.Kbd: nil, atom.Mark: nil,
+	atom.Blockquote: nil, atom.Pre: nil, atom.Code: {"class"},
+	atom.Ul: nil, atom.Ol: {"start"}, atom.Li: nil, atom.Dl: nil, atom.Dt: nil, atom.Dd: nil,
+	atom.Table: nil, atom.Thead: nil, atom.Tbody: nil, atom.Tr: nil, atom.Th: {"align"}, atom.Td: {"align"},
+	atom.A: {"href", "title"}, atom.Img: {"src", "alt", "title"},
+}
+
+// droppedElements 连同内容一起去掉的标签
+var droppedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Iframe: true, atom.Frame: true, atom.Frameset: true,
+	atom.Object: true, atom.Embed: true, atom.Applet: true, atom.Noscript: true, atom.Template: true,
+	atom.Textarea: true, atom.Title: true, atom.Svg: true, atom.Math: true, atom.Form: true,
+	atom.Input: true, atom.Button: true, atom.Select: true, atom.Link: true, atom.Meta: true, atom.Base: true,
+}
+
+var codeClass = regexp.MustCompile(`^language-[\w+#-]+$`)
+
+type sanitized struct {
+	html   string
+	links  []string
+	images []string
+}
+
+// sanitize 按白名单过滤 HTML 中的标签、属性和链接，同时收集外部链接和图片地址
+func sanitize(s string) sanitized {
+	var result sanitized
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(s), body)
+	if err != nil {
+		return result
+	}
+
+	seenLinks := make(map[string]bool)
+	seenImages := make(map[string]bool)
+	var b strings.Builder
+	for _, n := range nodes {
+		body.AppendChild(n)
+	}
+	cleanChildren(body, func(n *html.Node) {
+		switch n.DataAtom {
+		case atom.A:
+			if href := attr(n, "href"); isExternal(href) && !seenLinks[href] {
+				seenLinks[href] = true
+				result.links = append(result.links, href)
+			}
+		case atom.Img:
+			if src := attr(n, "src"); src != "" && !seenImages[src] {
+				seenImages[src] = true
+				result.images = append(result.images, src)
+			}
+		}
+	})
+	for c := body.FirstChild; c != nil; c = c.NextSibling {
+		html.Render(&b, c)
+	}
+	result.html = b.String()
+	return result
+}
+
+// cleanChildren 过滤 parent 的子节点，visit 在保留的元素上调用
+func cleanChildren(parent *html.Node, visit func(*html.Node)) {
+	for c := parent.FirstChild; c != nil; {
+		next := c.NextSibling
+		switch c.Type {
+		case html.TextNode:
+		case html.ElementNode:
+			if droppedElements[c.DataAtom] {
+				parent.RemoveChild(c)
+				break
+			}
+			allowed, ok := allowedAttrs[c.DataAtom]
+			if !ok || c.Namespace != "" {
+				// 去掉标签但保留内容，内容重新过滤
+				first := c.FirstChild
+				for gc := c.FirstChild; gc != nil; {
+					gcNext := gc.NextSibling
+					c.RemoveChild(gc)
+					parent.InsertBefore(gc, c)
+					gc = gcNext
+				}
+				parent.RemoveChild(c)
+				if first != nil {
+					next = first
+				}
+				break
+			}
+			c.Attr = cleanAttrs(c, allowed)
+			if c.DataAtom == atom.A && attr(c, "href") != "" {
+				c.Attr = append(c.Attr, html.Attribute{Key: "rel", Val: "nofollow noopener noreferrer"})
+			}
+			if c.DataAtom == atom.Img && attr(c, "src") == "" {
+				parent.RemoveChild(c)
+				break
+			}
+			visit(c)
+			cleanChildren(c, visit)
+		default:
+			// 注释、文档类型等
+			parent.RemoveChild(c)
+		}
+		c = next
+	}
+}
+
+func cleanAttrs(n *html.Node, allowed []string) []html.Attribute {
+	var attrs []html.Attribute
+	for _, a := range n.Attr {
+		if a.Namespace != "" || !contains(allowed, a.Key) {
+			continue
+		}
+		switch a.Key {
+		case "href", "src":
+			if !safeURL(a.Val, a.Key == "href") {
+				continue
+			}
+		case "class":
+			if !codeClass.MatchString(a.Val) {
+				continue
+			}
+		case "align":
+			if a.Val != "left" && a.Val != "right" && a.Val != "center" {
+				continue
+			}
+		}
+		attrs = append(attrs, a)
+	}
+	return attrs
+}
+
+// safeURL 只允许相对地址、页内锚点和 http、https 链接，href 还允许 mailto
+func safeURL(raw string, href bool) bool {
+	// 浏览器会忽略协议中的空白和控制字符，如 "java\tscript:"
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, raw)
+	if cleaned == "" {
+		return false
+	}
+	u, err := url.Parse(cleaned)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "":
+		return true
+	case "http", "https":
+		return true
+	case "mailto":
+		return href
+	}
+	return false
+}
+
+func isExternal(href string) bool {
+	u, err := url.Parse(href)
+	if err != nil {
+		return false
+	}
+	scheme := strings.ToLower(u.Scheme)
+	return (scheme == "http" || scheme == "https") && u.Host != ""
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
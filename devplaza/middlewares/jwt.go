@@ -10,55 +10,196 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func JWT(permission string) gin.HandlerFunc {
+// authError 认证失败时返回给客户端的状态码和信息
+type authError struct {
+	status  int
+	message string
+}
+
+// AuthOption 注册路由时调整认证行为
+type AuthOption func(*authOptions)
+
+type authOptions struct {
+	allowApiToken bool
+}
+
+// AllowApiToken 允许个人访问令牌调用不要求具体权限的接口。
+// 只用于在处理函数中按令牌范围内的权限再做校验的接口，或本身就是公开的接口；
+// 账号资料、登录身份、会话、关注等接口只接受登录令牌
+func AllowApiToken(o *authOptions) {
+	o.allowApiToken = true
+}
+
+func JWT(permission string, opts ...AuthOption) gin.HandlerFunc {
+	options := newAuthOptions(opts)
 	return func(c *gin.Context) {
-		tokenString := c.GetHeader("Authorization")
-		if tokenString == "" {
-			utils.ErrorResponse(c, http.StatusUnauthorized, "Please log in to continue!", nil)
+		if err := authenticate(c, permission, options); err != nil {
+			utils.ErrorResponse(c, err.status, err.message, nil)
 			c.Abort()
 			return
 		}
+		c.Next()
+	}
+}
 
-		parts := strings.Split(tokenString, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			utils.ErrorResponse(c, http.StatusUnauthorized, "Authentication failed, please try again.", nil)
-			c.Abort()
-			return
+// OptionalJWT 用于匿名用户也能访问的接口：未携带令牌或令牌无效（如已过期）时按匿名用户处理，
+// 令牌有效时与 JWT("") 相同
+func OptionalJWT(opts ...AuthOption) gin.HandlerFunc {
+	options := newAuthOptions(opts)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "" {
+			_ = authenticate(c, "", options)
 		}
+		c.Next()
+	}
+}
 
-		// 解析 Token
-		claims, err := utils.ParseToken(parts[1])
-		if err != nil {
-			utils.ErrorResponse(c, http.StatusUnauthorized, "Authentication failed, please try again.", nil)
-			c.Abort()
-			return
-		}
+func newAuthOptions(opts []AuthOption) authOptions {
+	var options authOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
 
-		perms, err := models.GetUserWithPermissions(claims.Uid)
-		if err != nil {
-			utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized action", nil)
-			c.Abort()
-			return
+// authenticate 校验令牌和接口要求的权限，成功后在上下文中写入 uid、permissions 等；
+// 失败时上下文中不写入任何用户信息
+func authenticate(c *gin.Context, permission string, options authOptions) *authError {
+	tokenString := c.GetHeader("Authorization")
+	if tokenString == "" {
+		return &authError{http.StatusUnauthorized, "Please log in to continue!"}
+	}
+
+	parts := strings.Split(tokenString, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return &authError{http.StatusUnauthorized, "Authentication failed, please try again."}
+	}
+
+	var auth *authResult
+	var err *authError
+	if strings.HasPrefix(parts[1], utils.ApiTokenPrefix) {
+		auth, err = authApiToken(c, parts[1])
+	} else {
+		auth, err = authJWT(c, parts[1])
+	}
+	if err != nil {
+		return err
+	}
+
+	// 个人访问令牌只能调用要求具体权限的接口和注册时标记了 AllowApiToken 的接口，
+	// 不能用来修改账号资料、管理登录身份、退出登录等
+	if auth.apiTokenId != 0 && permission == "" && !options.allowApiToken {
+		return &authError{http.StatusForbidden, "API tokens cannot access this endpoint"}
+	}
+
+	// 审核、删除类权限只在通过两步验证后生效
+	permissions := auth.permissions
+	if !auth.mfa {
+		if permission != "" && requiresMfa(permission) {
+			return &authError{http.StatusForbidden, "two-factor authentication required"}
 		}
+		permissions = withoutMfaPermissions(permissions)
+	}
 
-		if isEqual := utils.StringSlicesEqual(perms, claims.Permissions); !isEqual {
-			utils.ErrorResponse(c, http.StatusForbidden, " permission change", nil)
-			c.Abort()
-			return
+	// TODO: check in controller handle?
+	if permission != "" {
+		permSet := utils.ToSet(permissions)
+		if _, ok := permSet[permission]; !ok {
+			return &authError{http.StatusForbidden, "Unauthorized permission"}
 		}
+	}
+
+	c.Set("uid", auth.uid)
+	if auth.sid != "" {
+		c.Set("sid", auth.sid)
+	}
+	if auth.apiTokenId != 0 {
+		c.Set("api_token_id", auth.apiTokenId)
+	}
+	c.Set("mfa", auth.mfa)
+	c.Set("permissions", permissions)
+	return nil
+}
+
+// authResult 令牌校验通过后的用户信息
+type authResult struct {
+	uid         uint
+	sid         string // 登录令牌所属的会话（令牌族）
+	apiTokenId  uint   // 个人访问令牌 ID
+	mfa         bool
+	permissions []string
+}
+
+// authJWT 校验登录签发的访问令牌
+func authJWT(c *gin.Context, tokenString string) (*authResult, *authError) {
+	// 解析 Token
+	claims, err := utils.ParseToken(tokenString)
+	if err != nil {
+		return nil, &authError{http.StatusUnauthorized, "Authentication failed, please try again."}
+	}
+
+	// 不带令牌族的旧令牌无法吊销，要求重新登录；专用令牌不能访问接口
+	if claims.Sid == "" || claims.Purpose != "" {
+		return nil, &authError{http.StatusUnauthorized, "Please log in to continue!"}
+	}
+
+	// 会话被结束（退出登录、在其他设备上移除）后令牌立即失效
+	session, err := models.GetActiveSession(claims.Sid)
+	if err != nil || session.UserId != claims.Uid {
+		return nil, &authError{http.StatusUnauthorized, "Please log in to continue!"}
+	}
+	_ = models.TouchSession(session, c.ClientIP())
+
+	perms, err := models.GetUserWithPermissions(claims.Uid)
+	if err != nil {
+		return nil, &authError{http.StatusUnauthorized, "Unauthorized action"}
+	}
 
-		// TODO: check in controller handle?
-		if permission != "" {
-			permSet := utils.ToSet(claims.Permissions)
-			if _, ok := permSet[permission]; !ok {
-				utils.ErrorResponse(c, http.StatusForbidden, "Unauthorized permission", nil)
-				c.Abort()
-				return
-			}
+	if isEqual := utils.StringSlicesEqual(perms, claims.Permissions); !isEqual {
+		return nil, &authError{http.StatusForbidden, " permission change"}
+	}
+
+	return &authResult{uid: claims.Uid, sid: claims.Sid, mfa: claims.Mfa, permissions: claims.Permissions}, nil
+}
+
+// authApiToken 校验个人访问令牌，生效权限为令牌范围与用户当前权限的交集
+func authApiToken(c *gin.Context, tokenString string) (*authResult, *authError) {
+	token, err := models.GetActiveApiToken(utils.HashToken(tokenString))
+	if err != nil {
+		return nil, &authError{http.StatusUnauthorized, "Authentication failed, please try again."}
+	}
+
+	perms, err := models.GetUserWithPermissions(token.UserId)
+	if err != nil {
+		return nil, &authError{http.StatusUnauthorized, "Unauthorized action"}
+	}
+
+	permSet := utils.ToSet(perms)
+	scoped := make([]string, 0, len(token.Scopes))
+	for _, scope := range token.Scopes {
+		if _, ok := permSet[scope]; ok {
+			scoped = append(scoped, scope)
 		}
+	}
 
-		c.Set("uid", claims.Uid)
-		c.Set("permissions", claims.Permissions)
-		c.Next()
+	_ = models.TouchApiToken(token, c.ClientIP())
+
+	return &authResult{uid: token.UserId, apiTokenId: token.ID, mfa: token.MfaVerified, permissions: scoped}, nil
+}
+
+// requiresMfa 审核、删除和权限管理影响全站，要求两步验证
+func requiresMfa(permission string) bool {
+	return strings.HasSuffix(permission, ":review") ||
+		strings.HasSuffix(permission, ":delete") ||
+		permission == models.RbacManagePermission
+}
+
+func withoutMfaPermissions(permissions []string) []string {
+	filtered := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		if !requiresMfa(p) {
+			filtered = append(filtered, p)
+		}
 	}
+	return filtered
 }
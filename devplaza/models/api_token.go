@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// ApiToken 个人访问令牌，供脚本调用 API，权限限定在 Scopes 内
+type ApiToken struct {
+	gorm.Model
+	UserId     uint           `gorm:"index;not null" json:"user_id"`
+	Name       string         `json:"name"`
+	Prefix     string         `json:"prefix"` // 令牌开头几位，便于用户识别
+	TokenHash  string         `gorm:"uniqueIndex;not null" json:"-"`
+	Scopes     pq.StringArray `gorm:"type:text[]" json:"scopes"`
+	ExpiresAt  *time.Time     `json:"expires_at"`
+	LastUsedAt *time.Time     `json:"last_used_at"`
+	LastUsedIp string         `json:"last_used_ip"`
+	RevokedAt  *time.Time     `json:"revoked_at"`
+
+	MfaVerified bool `gorm:"default:false" json:"mfa_verified"` // 创建时的会话通过了两步验证
+}
+
+func (t *ApiToken) Create() error {
+	return db.Create(t).Error
+}
+
+func ListUserApiTokens(userId uint) ([]ApiToken, error) {
+	var tokens []ApiToken
+	err := db.Where("user_id = ? AND revoked_at IS NULL", userId).
+		Order("created_at desc").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+// GetActiveApiToken 查找未吊销、未过期的令牌
+func GetActiveApiToken(tokenHash string) (*ApiToken, error) {
+	var t ApiToken
+	err := db.Where("token_hash = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", tokenHash, time.Now()).
+		First(&t).Error
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// TouchApiToken 记录最近使用时间，一分钟内只写一次
+func TouchApiToken(t *ApiToken, ip string) error {
+	now := time.Now()
+	if t.LastUsedAt != nil && now.Sub(*t.LastUsedAt) < time.Minute {
+		return nil
+	}
+	return db.Model(t).UpdateColumns(map[string]interface{}{
+		"last_used_at": &now,
+		"last_used_ip": ip,
+	}).Error
+}
+
+func RevokeApiToken(userId, id uint) error {
+	now := time.Now()
+	res := db.Model(&ApiToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userId).
+		Update("revoked_at", &now)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -23,16 +23,32 @@ type Article struct {
 	PublisherId   uint           `json:"publisher_id"`
 	Publisher     *User          `gorm:"foreignKey:PublisherId" json:"publisher"`
 	PublishTime   *time.Time     `json:"publish_time"`
-	PublishStatus uint           `gorm:"default:1" json:"publish_status"` // 0:全部 1:待审核 2:已发布
+	PublishAt     *time.Time     `json:"publish_at"`                      // 定时发布时间
+	PublishStatus uint           `gorm:"default:1" json:"publish_status"` // 见 workflow.Status，1:待审核 2:已发布 3:草稿 4:需修改 5:已下架 6:已归档 7:定时发布
 	ViewCount     uint           `gorm:"default:0" json:"view_count"`
+
+	RenderedContent // 正文处理后的 HTML、目录和统计信息
+
+	// 多语言，原文和译文属于同一个翻译组
+	Language                 string `gorm:"default:zh;index" json:"language"`  // zh 或 en
+	TranslationGroupId       *uint  `gorm:"index" json:"translation_group_id"` // 原文的 ID，原文被翻译后也会设置为自身 ID
+	TranslationSourceVersion int    `json:"translation_source_version"`        // 译文最近一次同步时原文的修订版本
+	TranslationOutdated      bool   `gorm:"-" json:"translation_outdated"`     // 原文在翻译后又有修改
+
+	Contributors []Contributor `gorm:"polymorphicType:ContentType;polymorphicId:ContentId;polymorphicValue:blog" json:"contributors"`
 }
 
 func (a *Article) Create() error {
 	return db.Create(a).Error
 }
 
+// Load 读取博客，不增加浏览量，用于编辑、审核等管理操作
+func (a *Article) Load(id uint) error {
+	return db.Preload("Publisher").Preload("Contributors", "accepted_at IS NOT NULL").Preload("Contributors.User").First(a, id).Error
+}
+
 func (a *Article) GetByID(id uint) error {
-	if err := db.Preload("Publisher").First(a, id).Error; err != nil {
+	if err := a.Load(id); err != nil {
 		return err
 	}
 
@@ -63,8 +79,9 @@ type ArticleFilter struct {
 	OrderDesc     bool   // 是否按发布时间排序
 	PublishStatus int    // 发布状态
 	PublisherId   int
-	Page          int // 当前页码，从 1 开始
-	PageSize      int // 每页数量，建议默认 10
+	Language      string // 语言，如 zh、en
+	Page          int    // 当前页码，从 1 开始
+	PageSize      int    // 每页数量，建议默认 10
 }
 
 func QueryArticles(filter ArticleFilter) ([]Article, int64, error) {
@@ -94,6 +111,10 @@ func QueryArticles(filter ArticleFilter) ([]Article, int64, error) {
 		query = query.Where("publisher_id = ?", filter.PublisherId)
 	}
 
+	if filter.Language != "" {
+		query = query.Where("language = ?", filter.Language)
+	}
+
 	// 统计总数（不加 limit 和 offset）
 	query.Count(&total)
 
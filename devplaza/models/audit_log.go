@@ -0,0 +1,110 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"time"
+)
+
+// JSONB PostgreSQL jsonb 字段，原样输出 JSON
+type JSONB []byte
+
+func (j JSONB) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return nil, nil
+	}
+	return string(j), nil
+}
+
+func (j *JSONB) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*j = nil
+	case []byte:
+		*j = append((*j)[:0], v...)
+	case string:
+		*j = JSONB(v)
+	default:
+		return errors.New("unsupported jsonb value")
+	}
+	return nil
+}
+
+func (j JSONB) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+// AuditLog 审计日志：记录审核、删除、角色和用户资料等敏感操作，只增不改
+type AuditLog struct {
+	ID           uint      `gorm:"primarykey" json:"id"`
+	CreatedAt    time.Time `gorm:"index" json:"created_at"`
+	ActorId      uint      `gorm:"index" json:"actor_id"`
+	ApiTokenId   *uint     `json:"api_token_id"` // 通过个人访问令牌操作时记录令牌 ID
+	Action       string    `gorm:"index;not null" json:"action"`
+	ResourceType string    `gorm:"index:idx_audit_resource;not null" json:"resource_type"`
+	ResourceId   uint      `gorm:"index:idx_audit_resource" json:"resource_id"`
+	Before       JSONB     `gorm:"type:jsonb" json:"before"`
+	After        JSONB     `gorm:"type:jsonb" json:"after"`
+	Ip           string    `json:"ip"`
+	UserAgent    string    `json:"user_agent"`
+	Method       string    `json:"method"`
+	Path         string    `json:"path"`
+}
+
+type AuditLogFilter struct {
+	ActorId      uint
+	Action       string
+	ResourceType string
+	ResourceId   uint
+	From         *time.Time
+	To           *time.Time
+	Page         int // 当前页码，从 1 开始
+	PageSize     int // 每页数量
+}
+
+func (a *AuditLog) Create() error {
+	return db.Create(a).Error
+}
+
+func QueryAuditLogs(filter AuditLogFilter) ([]AuditLog, int64, error) {
+	var logs []AuditLog
+	var total int64
+
+	query := db.Model(&AuditLog{})
+
+	if filter.ActorId != 0 {
+		query = query.Where("actor_id = ?", filter.ActorId)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.ResourceType != "" {
+		query = query.Where("resource_type = ?", filter.ResourceType)
+	}
+	if filter.ResourceId != 0 {
+		query = query.Where("resource_id = ?", filter.ResourceId)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	// 统计总数（不加 limit 和 offset）
+	query.Count(&total)
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = 20
+	}
+	offset := (filter.Page - 1) * filter.PageSize
+
+	err := query.Order("id desc").Limit(filter.PageSize).Offset(offset).Find(&logs).Error
+	return logs, total, err
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"devplaza/markdown"
+	"encoding/json"
+	"log"
+
+	"github.com/lib/pq"
+)
+
+// RenderedContent 服务端处理正文 Markdown 的结果，保存博客、教程时生成
+type RenderedContent struct {
+	ContentHtml string         `gorm:"type:text" json:"content_html"` // 过滤危险标签后的 HTML
+	Toc         JSONB          `gorm:"type:jsonb" json:"toc"`         // 目录，见 markdown.Heading
+	WordCount   int            `gorm:"default:0" json:"word_count"`
+	ReadingTime int            `gorm:"default:0" json:"reading_time"` // 预计阅读分钟数
+	Links       pq.StringArray `gorm:"type:text[]" json:"links"`      // 正文中的外部链接
+	Images      pq.StringArray `gorm:"type:text[]" json:"images"`     // 正文中的图片地址
+}
+
+func renderContent(content string) RenderedContent {
+	result := markdown.Process(content)
+	toc := []markdown.Heading{}
+	if result.Toc != nil {
+		toc = result.Toc
+	}
+	tocJson, _ := json.Marshal(toc)
+	return RenderedContent{
+		ContentHtml: result.Html,
+		Toc:         tocJson,
+		WordCount:   result.WordCount,
+		ReadingTime: result.ReadingTime,
+		Links:       pq.StringArray(nonNilStrings(result.Links)),
+		Images:      pq.StringArray(nonNilStrings(result.Images)),
+	}
+}
+
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// MigrateRenderedContent 为还没有处理过正文的博客、教程生成 HTML、目录等字段
+func MigrateRenderedContent() {
+	const batchSize = 100
+	for _, table := range []string{"articles", "tutorials"} {
+		type row struct {
+			Id      uint
+			Content string
+		}
+		var lastId uint
+		for {
+			var rows []row
+			err := db.Table(table).Select("id, content").
+				Where("toc IS NULL AND id > ?", lastId).
+				Order("id asc").Limit(batchSize).
+				Scan(&rows).Error
+			if err != nil {
+				log.Printf("Migrate %s rendered content failed: %v", table, err)
+				break
+			}
+			for _, r := range rows {
+				rendered := renderContent(r.Content)
+				err := db.Table(table).Where("id = ?", r.Id).UpdateColumns(map[string]interface{}{
+					"content_html": rendered.ContentHtml,
+					"toc":          rendered.Toc,
+					"word_count":   rendered.WordCount,
+					"reading_time": rendered.ReadingTime,
+					"links":        rendered.Links,
+					"images":       rendered.Images,
+				}).Error
+				if err != nil {
+					log.Printf("Migrate %s %d rendered content failed: %v", table, r.Id, err)
+				}
+			}
+			if len(rows) < batchSize {
+				break
+			}
+			lastId = rows[len(rows)-1].Id
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidContributor = errors.New("invalid contributor")
+	ErrInvitationAccepted = errors.New("invitation already accepted")
+)
+
+// 贡献者角色
+const (
+	ContributorAuthor     = "author"
+	ContributorTranslator = "translator"
+	ContributorEditor     = "editor"
+)
+
+// Contributor 博客、教程的共同作者、译者和编辑，与发布者一样可以修改内容，并在个人主页中展示。
+// 发布者添加的贡献者需要本人接受邀请（AcceptedAt 不为空）后才生效
+type Contributor struct {
+	ID          uint       `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ContentType string     `gorm:"uniqueIndex:idx_contributor;not null" json:"content_type"`
+	ContentId   uint       `gorm:"uniqueIndex:idx_contributor;not null" json:"content_id"`
+	UserId      uint       `gorm:"uniqueIndex:idx_contributor;index;not null" json:"user_id"`
+	User        *User      `gorm:"foreignKey:UserId" json:"user,omitempty"`
+	Role        string     `gorm:"not null" json:"role"` // author、translator、editor
+	AcceptedAt  *time.Time `json:"accepted_at"`
+}
+
+// ContributorInvitation 用户收到的贡献者邀请
+type ContributorInvitation struct {
+	Contributor
+	Title string `json:"title"`
+}
+
+// Contribution 用户参与的已发布内容
+type Contribution struct {
+	ContentType string     `json:"content_type"`
+	ContentId   uint       `json:"content_id"`
+	Title       string     `json:"title"`
+	Role        string     `json:"role"`
+	PublishTime *time.Time `json:"publish_time"`
+}
+
+// ListContributors 内容的全部贡献者，包括尚未接受邀请的
+func ListContributors(contentType string, contentId uint) ([]Contributor, error) {
+	var contributors []Contributor
+	err := db.Preload("User").
+		Where("content_type = ? AND content_id = ?", contentType, contentId).
+		Order("id asc").
+		Find(&contributors).Error
+	return contributors, err
+}
+
+// SetContributors 用 contributors 替换内容原有的贡献者，用户不存在时返回 ErrInvalidContributor。
+// 原有贡献者保留是否已接受邀请，返回新邀请的贡献者
+func SetContributors(contentType string, contentId uint, contributors []Contributor) ([]Contributor, error) {
+	ids := make([]uint, 0, len(contributors))
+	for _, c := range contributors {
+		ids = append(ids, c.UserId)
+	}
+	if len(ids) > 0 {
+		var count int64
+		if err := db.Model(&User{}).Where("id IN ?", ids).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if int(count) != len(ids) {
+			return nil, ErrInvalidContributor
+		}
+	}
+
+	var invited []Contributor
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var existing []Contributor
+		if err := tx.Where("content_type = ? AND content_id = ?", contentType, contentId).
+			Find(&existing).Error; err != nil {
+			return err
+		}
+		accepted := make(map[uint]*time.Time, len(existing))
+		for _, e := range existing {
+			accepted[e.UserId] = e.AcceptedAt
+		}
+
+		if err := tx.Where("content_type = ? AND content_id = ?", contentType, contentId).
+			Delete(&Contributor{}).Error; err != nil {
+			return err
+		}
+		if len(contributors) == 0 {
+			return nil
+		}
+		for i := range contributors {
+			acceptedAt, ok := accepted[contributors[i].UserId]
+			contributors[i].ID = 0
+			contributors[i].ContentType = contentType
+			contributors[i].ContentId = contentId
+			contributors[i].User = nil
+			contributors[i].AcceptedAt = acceptedAt
+			if !ok {
+				invited = append(invited, contributors[i])
+			}
+		}
+		return tx.Create(&contributors).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return invited, nil
+}
+
+// ListContributorInvitations 用户尚未接受的贡献者邀请，最新的在前
+func ListContributorInvitations(userId uint) ([]ContributorInvitation, error) {
+	invitations := []ContributorInvitation{}
+	err := db.Raw(`
+		SELECT ct.*, c.title FROM contributors ct JOIN articles c ON c.id = ct.content_id
+		WHERE ct.content_type = ? AND ct.user_id = ? AND ct.accepted_at IS NULL AND c.deleted_at IS NULL
+		UNION ALL
+		SELECT ct.*, c.title FROM contributors ct JOIN tutorials c ON c.id = ct.content_id
+		WHERE ct.content_type = ? AND ct.user_id = ? AND ct.accepted_at IS NULL AND c.deleted_at IS NULL
+		ORDER BY created_at DESC
+	`, ContentBlog, userId, ContentTutorial, userId).Scan(&invitations).Error
+	return invitations, err
+}
+
+// AcceptContributorInvitation 接受贡献者邀请，邀请不存在时返回 gorm.ErrRecordNotFound
+func AcceptContributorInvitation(userId, id uint) (*Contributor, error) {
+	var contributor Contributor
+	if err := db.Where("id = ? AND user_id = ?", id, userId).First(&contributor).Error; err != nil {
+		return nil, err
+	}
+	if contributor.AcceptedAt != nil {
+		return nil, ErrInvitationAccepted
+	}
+	now := time.Now()
+	res := db.Model(&contributor).Where("accepted_at IS NULL").Update("accepted_at", &now)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrInvitationAccepted
+	}
+	return &contributor, nil
+}
+
+// RemoveContributor 拒绝邀请或退出已参与的内容，记录不存在时返回 gorm.ErrRecordNotFound
+func RemoveContributor(userId, id uint) (*Contributor, error) {
+	var contributor Contributor
+	if err := db.Where("id = ? AND user_id = ?", id, userId).First(&contributor).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Delete(&contributor).Error; err != nil {
+		return nil, err
+	}
+	return &contributor, nil
+}
+
+// ListUserContributions 用户作为贡献者参与（已接受邀请）的已发布博客和教程，最新发布的在前
+func ListUserContributions(userId uint) ([]Contribution, error) {
+	contributions := []Contribution{}
+	err := db.Raw(`
+		SELECT ct.content_type, ct.content_id, c.title, ct.role, c.publish_time
+		FROM contributors ct JOIN articles c ON c.id = ct.content_id
+		WHERE ct.content_type = ? AND ct.user_id = ? AND ct.accepted_at IS NOT NULL
+			AND c.deleted_at IS NULL AND c.publish_status = 2
+		UNION ALL
+		SELECT ct.content_type, ct.content_id, c.title, ct.role, c.publish_time
+		FROM contributors ct JOIN tutorials c ON c.id = ct.content_id
+		WHERE ct.content_type = ? AND ct.user_id = ? AND ct.accepted_at IS NOT NULL
+			AND c.deleted_at IS NULL AND c.publish_status = 2
+		ORDER BY publish_time DESC NULLS LAST
+	`, ContentBlog, userId, ContentTutorial, userId).Scan(&contributions).Error
+	return contributions, err
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"devplaza/workflow"
 	"errors"
 
 	"github.com/lib/pq"
@@ -22,6 +23,10 @@ type Dapp struct {
 	User        *User          `gorm:"foreignKey:UserId"`
 	Tutorials   []Tutorial     `gorm:"foreignKey:DappId" json:"tutorials"`
 	IsFeature   uint           `gorm:"default:2" json:"is_feature"` // 0: all 1: 是 2:不是
+
+	// 收录审核，已有的 Dapp 视为已通过
+	PublishStatus uint   `gorm:"default:2" json:"publish_status"` // 见 workflow.Status，1:待审核 2:已通过 8:已拒绝
+	RejectReason  string `json:"reject_reason"`
 }
 
 func (d *Dapp) Create() error {
@@ -52,6 +57,8 @@ type DappFilter struct {
 	MainCategories []uint
 	SubCategories  []uint
 	IsFeature      uint
+	PublishStatus  uint // 0: 不限
+	VisibleTo      uint // 非 0 时只返回已通过的和该用户提交的 Dapp
 	OrderDesc      bool // 是否按创建时间倒序
 	Page           int  // 当前页码，从 1 开始
 	PageSize       int  // 每页数量，建议默认 10
@@ -85,6 +92,14 @@ func QueryDapps(filter DappFilter) ([]Dapp, int64, error) {
 		query = query.Where("is_feature = ?", filter.IsFeature)
 	}
 
+	if filter.PublishStatus != 0 {
+		query = query.Where("dapps.publish_status = ?", filter.PublishStatus)
+	}
+
+	if filter.VisibleTo != 0 {
+		query = query.Where("(dapps.publish_status = ? OR dapps.user_id = ?)", uint(workflow.Published), filter.VisibleTo)
+	}
+
 	// 统计总数（不加 limit 和 offset）
 	query.Count(&total)
 
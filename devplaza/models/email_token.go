@@ -0,0 +1,58 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	EmailTokenVerify = "verify_email"
+	EmailTokenReset  = "reset_password"
+)
+
+var ErrInvalidEmailToken = errors.New("invalid or expired token")
+
+// EmailToken 通过邮件发送的一次性令牌（邮箱验证、重置密码）
+type EmailToken struct {
+	gorm.Model
+	UserId    uint       `gorm:"index;not null" json:"user_id"`
+	Purpose   string     `gorm:"not null" json:"purpose"`
+	TokenHash string     `gorm:"uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+func (t *EmailToken) Create() error {
+	return db.Create(t).Error
+}
+
+// ConsumeEmailToken 使用令牌，成功后令牌失效
+func ConsumeEmailToken(purpose, tokenHash string) (*EmailToken, error) {
+	var t EmailToken
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("token_hash = ? AND purpose = ?", tokenHash, purpose).First(&t).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidEmailToken
+			}
+			return err
+		}
+
+		now := time.Now()
+		res := tx.Model(&EmailToken{}).
+			Where("id = ? AND used_at IS NULL AND expires_at > ?", t.ID, now).
+			Update("used_at", &now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrInvalidEmailToken
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
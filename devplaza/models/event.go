@@ -24,8 +24,9 @@ type Event struct {
 	Tags                 pq.StringArray `gorm:"type:text[]" json:"tags"`
 	Participants         uint           `json:"participants"`
 	Status               uint           `gorm:"default:0" json:"status"`         // 0: 未开始，1: 进行中 2: 已结束 TODO: 定时器更新状态？
-	PublishStatus        uint           `gorm:"default:1" json:"publish_status"` // 0: 所有  1: 待审核 2: 已发布
+	PublishStatus        uint           `gorm:"default:1" json:"publish_status"` // 见 workflow.Status，1:待审核 2:已发布 3:草稿 4:需修改 5:已下架 6:已归档 7:定时发布
 	PublishTime          *time.Time     `json:"publish_time"`
+	PublishAt            *time.Time     `json:"publish_at"` // 定时发布时间
 	Twitter              string         `json:"twitter"`
 	UserId               uint           `json:"user_id"`
 	User                 *User          `gorm:"foreignKey:UserId"`
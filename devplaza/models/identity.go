@@ -0,0 +1,158 @@
+package models
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrLastIdentity = errors.New("cannot unlink the last identity")
+
+// PasswordProvider 本地账号在 UserIdentity 中的 provider，subject 为小写邮箱
+const PasswordProvider = "password"
+
+// UserIdentity 用户关联的登录身份，同一账号可以通过 OAuth、GitHub、钱包等多种方式登录
+type UserIdentity struct {
+	gorm.Model
+	UserId        uint   `gorm:"index;not null" json:"user_id"`
+	Provider      string `gorm:"uniqueIndex:idx_identity_provider_subject;not null" json:"provider"`
+	Subject       string `gorm:"uniqueIndex:idx_identity_provider_subject;not null" json:"subject"`
+	Email         string `json:"email"` // 提供方验证过的邮箱
+	EmailVerified bool   `json:"email_verified"`
+	Address       string `gorm:"index" json:"address"` // 钱包地址
+}
+
+func (i *UserIdentity) Create() error {
+	return db.Create(i).Error
+}
+
+func GetIdentity(provider, subject string) (*UserIdentity, error) {
+	var i UserIdentity
+	if err := db.Where("provider = ? AND subject = ?", provider, subject).First(&i).Error; err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+func MarkIdentityEmailVerified(provider, subject string) error {
+	return db.Model(&UserIdentity{}).
+		Where("provider = ? AND subject = ?", provider, subject).
+		Update("email_verified", true).Error
+}
+
+// HasVerifiedEmail 用户邮箱是否已验证：本地验证过，或由关联身份的提供方验证过
+func (u *User) HasVerifiedEmail() (bool, error) {
+	if u.EmailVerifiedAt != nil {
+		return true, nil
+	}
+	var count int64
+	err := db.Model(&UserIdentity{}).
+		Where("user_id = ? AND email = ? AND email_verified", u.ID, u.Email).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ChangeUserEmail 修改用户邮箱，新邮箱需要重新验证：清除验证时间，
+// 未使用的验证、重置密码邮件失效，本地账号的登录身份同步为新邮箱
+func ChangeUserEmail(u *User, email string) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(u).UpdateColumns(map[string]interface{}{
+			"email":             email,
+			"email_verified_at": nil,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&EmailToken{}).
+			Where("user_id = ? AND used_at IS NULL", u.ID).
+			Update("expires_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Model(&UserIdentity{}).
+			Where("user_id = ? AND provider = ?", u.ID, PasswordProvider).
+			UpdateColumns(map[string]interface{}{
+				"subject":        email,
+				"email":          email,
+				"email_verified": false,
+			}).Error
+	})
+	if err != nil {
+		return err
+	}
+	u.Email = email
+	u.EmailVerifiedAt = nil
+	return nil
+}
+
+func ListUserIdentities(userId uint) ([]UserIdentity, error) {
+	var identities []UserIdentity
+	err := db.Where("user_id = ?", userId).Order("created_at asc").Find(&identities).Error
+	return identities, err
+}
+
+// DeleteUserIdentity 解除关联，至少保留一个登录身份
+func DeleteUserIdentity(userId, id uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&UserIdentity{}).Where("user_id = ?", userId).Count(&count).Error; err != nil {
+			return err
+		}
+		if count <= 1 {
+			return ErrLastIdentity
+		}
+
+		var identity UserIdentity
+		if err := tx.Where("id = ? AND user_id = ?", id, userId).First(&identity).Error; err != nil {
+			return err
+		}
+		// 硬删除，便于以后重新关联同一身份
+		if err := tx.Unscoped().Delete(&identity).Error; err != nil {
+			return err
+		}
+
+		// 同时清除 users 表中旧的 OAuth Uid 和钱包地址
+		switch identity.Provider {
+		case "oauth":
+			return tx.Model(&User{}).Where("id = ? AND uid::text = ?", userId, identity.Subject).
+				UpdateColumn("uid", 0).Error
+		case "siwe":
+			return tx.Model(&User{}).Where("id = ? AND address = ?", userId, identity.Address).
+				UpdateColumn("address", "").Error
+		}
+		return nil
+	})
+}
+
+// MigrateUserIdentities 将 users 表中的 OAuth Uid 和钱包地址回填为身份记录，只执行一次，
+// 之后解除关联的身份不会在重启时被重新回填
+func MigrateUserIdentities() {
+	err := runOnce("backfill_user_identities", func(tx *gorm.DB) error {
+		if err := tx.Exec(`
+			INSERT INTO user_identities (created_at, updated_at, user_id, provider, subject, email, email_verified)
+			SELECT NOW(), NOW(), id, 'oauth', uid::text, email, true
+			FROM users WHERE uid <> 0 AND deleted_at IS NULL
+			ON CONFLICT DO NOTHING
+		`).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Exec(`
+			INSERT INTO user_identities (created_at, updated_at, user_id, provider, subject, address)
+			SELECT NOW(), NOW(), id, 'siwe', address, address
+			FROM users WHERE address <> '' AND deleted_at IS NULL
+			ON CONFLICT DO NOTHING
+		`).Error; err != nil {
+			return err
+		}
+
+		// 早期钱包用户的占位邮箱统一为 <provider>.<subject>@users.devplaza
+		return tx.Exec(`
+			UPDATE users SET email = 'siwe.' || split_part(email, '@', 1) || '@users.devplaza'
+			WHERE email LIKE '%@wallet.devplaza'
+		`).Error
+	})
+	if err != nil {
+		log.Println("Migrate user identities failed:", err)
+	}
+}
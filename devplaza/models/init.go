@@ -2,14 +2,17 @@ package models
 
 import (
 	"devplaza/config"
+	"log"
 )
 
 var db = config.DB
 
 func init() {
+	db.AutoMigrate(&Migration{})
 	db.AutoMigrate(&Permission{})
 	db.AutoMigrate(&PermissionGroup{})
 	db.AutoMigrate(&Role{})
+	db.AutoMigrate(&RbacSeed{})
 	db.AutoMigrate(&User{})
 	db.AutoMigrate(&Event{})
 	db.AutoMigrate(&Recap{})
@@ -24,7 +27,35 @@ func init() {
 	db.AutoMigrate(&PostFavorite{})
 	db.AutoMigrate(&DailyStats{})
 	db.AutoMigrate(&Follow{})
+	db.AutoMigrate(&RefreshToken{})
+	db.AutoMigrate(&SiweNonce{})
+	db.AutoMigrate(&UserIdentity{})
+	db.AutoMigrate(&EmailToken{})
+	db.AutoMigrate(&ApiToken{})
+	db.AutoMigrate(&SigningKey{})
+	db.AutoMigrate(&Session{})
+	db.AutoMigrate(&RecoveryCode{})
+	db.AutoMigrate(&UsedMfaToken{})
+	db.AutoMigrate(&ModeratorAssignment{})
+	db.AutoMigrate(&AuditLog{})
+	db.AutoMigrate(&PublishTransition{})
+	db.AutoMigrate(&Revision{})
+	db.AutoMigrate(&ReviewAssignment{})
+	db.AutoMigrate(&ReviewComment{})
+	db.AutoMigrate(&Contributor{})
+	db.AutoMigrate(&Series{})
 
-	InitRolesAndPermissions()
+	if err := ReconcileRolesAndPermissions(); err != nil {
+		log.Fatalf("Reconcile roles and permissions failed: %v", err)
+	}
 	InitCategories()
+	MigrateUserIdentities()
+	MigrateSessions()
+	MigrateUserRoles()
+	MigrateRevisions()
+	MigrateRenderedContent()
+
+	if err := RotateSigningKeys(); err != nil {
+		log.Fatalf("Init signing keys failed: %v", err)
+	}
 }
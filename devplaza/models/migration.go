@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration 已执行过的一次性数据迁移
+type Migration struct {
+	Name      string    `gorm:"primaryKey"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// runOnce 执行一次性数据迁移，成功后记录名称，之后启动时跳过
+func runOnce(name string, fn func(tx *gorm.DB) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&Migration{}).Where("name = ?", name).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return tx.Create(&Migration{Name: name, AppliedAt: time.Now()}).Error
+	})
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var ErrModeratorAssigned = errors.New("moderator already assigned")
+
+// 审核范围类型
+const (
+	ModeratorScopeCategory = "category"
+	ModeratorScopeDapp     = "dapp"
+)
+
+// ModeratorAssignment 限定范围的审核员，例如只审核 DeFi 分类下的 Dapp，或只审核某个 Dapp 的教程
+type ModeratorAssignment struct {
+	gorm.Model
+	UserId     uint   `gorm:"uniqueIndex:idx_moderator_scope;not null" json:"user_id"`
+	User       *User  `gorm:"foreignKey:UserId" json:"user,omitempty"`
+	Permission string `gorm:"uniqueIndex:idx_moderator_scope;not null" json:"permission"` // 如 tutorial:review
+	ScopeType  string `gorm:"uniqueIndex:idx_moderator_scope;not null" json:"scope_type"` // category 或 dapp
+	ScopeId    uint   `gorm:"uniqueIndex:idx_moderator_scope;not null" json:"scope_id"`
+}
+
+type ModeratorFilter struct {
+	UserId    uint
+	ScopeType string
+	ScopeId   uint
+	Page      int
+	PageSize  int
+}
+
+func (m *ModeratorAssignment) Create() error {
+	var count int64
+	db.Model(&ModeratorAssignment{}).
+		Where("user_id = ? AND permission = ? AND scope_type = ? AND scope_id = ?", m.UserId, m.Permission, m.ScopeType, m.ScopeId).
+		Count(&count)
+	if count > 0 {
+		return ErrModeratorAssigned
+	}
+	return db.Create(m).Error
+}
+
+// DeleteModeratorAssignment 撤销审核员指派（硬删除，允许之后重新指派）
+func DeleteModeratorAssignment(id uint) (*ModeratorAssignment, error) {
+	var m ModeratorAssignment
+	if err := db.First(&m, id).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Unscoped().Delete(&m).Error; err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func QueryModeratorAssignments(filter ModeratorFilter) ([]ModeratorAssignment, int64, error) {
+	var assignments []ModeratorAssignment
+	var total int64
+
+	query := db.Preload("User").Model(&ModeratorAssignment{})
+
+	if filter.UserId != 0 {
+		query = query.Where("user_id = ?", filter.UserId)
+	}
+	if filter.ScopeType != "" {
+		query = query.Where("scope_type = ?", filter.ScopeType)
+	}
+	if filter.ScopeId != 0 {
+		query = query.Where("scope_id = ?", filter.ScopeId)
+	}
+
+	query.Count(&total)
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = 10
+	}
+	offset := (filter.Page - 1) * filter.PageSize
+
+	err := query.Order("id desc").Limit(filter.PageSize).Offset(offset).Find(&assignments).Error
+	return assignments, total, err
+}
+
+// ListUserModeratorAssignments 用户的全部审核范围
+func ListUserModeratorAssignments(userId uint) ([]ModeratorAssignment, error) {
+	var assignments []ModeratorAssignment
+	err := db.Where("user_id = ?", userId).Find(&assignments).Error
+	return assignments, err
+}
+
+// CategoryLineage 分类及其所有上级分类的 ID，上级分类的审核员同样可以审核子分类
+func CategoryLineage(categoryId uint) []uint {
+	ids := []uint{}
+	for categoryId != 0 && len(ids) < 10 {
+		ids = append(ids, categoryId)
+
+		var c Category
+		if err := db.Select("id", "parent_id").First(&c, categoryId).Error; err != nil || c.ParentId == nil {
+			break
+		}
+		categoryId = *c.ParentId
+	}
+	return ids
+}
+
+// ArticleCategoryIds 博客分类（自由填写的名称）对应的分类及其上级分类 ID，
+// 按分类名或全名匹配，不区分大小写，找不到时为空
+func ArticleCategoryIds(category string) []uint {
+	ids := []uint{}
+	if category == "" {
+		return ids
+	}
+	var matched []uint
+	if err := db.Model(&Category{}).
+		Where("LOWER(name) = LOWER(?) OR LOWER(full_name) = LOWER(?)", category, category).
+		Pluck("id", &matched).Error; err != nil {
+		return ids
+	}
+	for _, id := range matched {
+		ids = append(ids, CategoryLineage(id)...)
+	}
+	return ids
+}
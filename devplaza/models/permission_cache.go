@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"devplaza/utils"
+
+	"github.com/spf13/viper"
+)
+
+// 用户权限缓存，避免每个请求都查询角色、权限组和权限
+// 角色、权限组或权限关系变化时必须调用 Invalidate* 清除
+var permissionCache = utils.NewTTLCache[uint, []string](permissionCacheTTL())
+
+func permissionCacheTTL() time.Duration {
+	if ttl := viper.GetDuration("cache.permissionTtl"); ttl > 0 {
+		return ttl
+	}
+	return time.Minute
+}
+
+// InvalidateUserPermissions 清除单个用户的权限缓存
+func InvalidateUserPermissions(userId uint) {
+	permissionCache.Delete(userId)
+}
+
+// InvalidateAllPermissions 角色或权限组变化时清除全部缓存
+func InvalidateAllPermissions() {
+	permissionCache.Clear()
+}
+
+// PermissionCacheStats 权限缓存命中率
+func PermissionCacheStats() utils.CacheStats {
+	return permissionCache.Stats()
+}
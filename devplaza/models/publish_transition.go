@@ -0,0 +1,134 @@
+package models
+
+import (
+	"devplaza/workflow"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrPublishStatusChanged = errors.New("publish status changed")
+
+// 走发布流程的内容类型
+const (
+	ContentBlog     = "blog"
+	ContentTutorial = "tutorial"
+	ContentEvent    = "event"
+	ContentDapp     = "dapp"
+)
+
+// PublishTransition 博客、教程、活动的发布状态及 Dapp 收录状态的变更记录，只增不改
+type PublishTransition struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	ContentType string    `gorm:"index:idx_transition_content;not null" json:"content_type"`
+	ContentId   uint      `gorm:"index:idx_transition_content;not null" json:"content_id"`
+	FromStatus  uint      `json:"from_status"`
+	ToStatus    uint      `json:"to_status"`
+	ActorId     uint      `json:"actor_id"`
+	Actor       *User     `gorm:"foreignKey:ActorId" json:"actor,omitempty"`
+	Comment     string    `gorm:"type:text" json:"comment"` // 驳回、下架的原因
+}
+
+func (t *PublishTransition) Create() error {
+	return db.Create(t).Error
+}
+
+// ChangePublishStatus 在事务中更新内容的发布状态和 updates 中的字段（如发布时间），并写入变更记录；
+// 审核通过（发布或定时发布）时将最新修订版本标记为审核通过的版本，离开审核队列时清除审核负责人。
+// 若内容状态已被其他请求修改则返回 ErrPublishStatusChanged
+func ChangePublishStatus(content interface{}, t *PublishTransition, updates map[string]interface{}) error {
+	if updates == nil {
+		updates = map[string]interface{}{}
+	}
+	updates["publish_status"] = t.ToStatus
+	return db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(content).Where("publish_status = ?", t.FromStatus).Updates(updates)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrPublishStatusChanged
+		}
+		// 记录审核通过的版本，便于审核员查看之后的改动
+		if to := workflow.Status(t.ToStatus); to == workflow.Published || to == workflow.Scheduled {
+			if err := markRevisionApproved(tx, t.ContentType, t.ContentId, time.Now()); err != nil {
+				return err
+			}
+		}
+		// 审核队列只包含待审核和等待定时发布的内容
+		if to := workflow.Status(t.ToStatus); to != workflow.PendingReview && to != workflow.Scheduled {
+			if err := tx.Where("content_type = ? AND content_id = ?", t.ContentType, t.ContentId).
+				Delete(&ReviewAssignment{}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(t).Error
+	})
+}
+
+// SetPublishAt 设置或清除定时发布时间，内容状态已不是 status 时返回 ErrPublishStatusChanged
+func SetPublishAt(content interface{}, status uint, publishAt *time.Time) error {
+	res := db.Model(content).Where("publish_status = ?", status).Update("publish_at", publishAt)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrPublishStatusChanged
+	}
+	return nil
+}
+
+// scheduledContent 支持定时发布的内容及其模型
+var scheduledContent = []struct {
+	contentType string
+	model       func(id uint) interface{}
+}{
+	{ContentBlog, func(id uint) interface{} { a := &Article{}; a.ID = id; return a }},
+	{ContentTutorial, func(id uint) interface{} { t := &Tutorial{}; t.ID = id; return t }},
+	{ContentEvent, func(id uint) interface{} { e := &Event{}; e.ID = id; return e }},
+}
+
+// PublishScheduledContent 发布已到定时发布时间的内容，发布时间记为计划的时间，返回发布的数量。
+// 多个实例同时执行时由状态检查保证每条内容只发布一次
+func PublishScheduledContent() (int, error) {
+	published := 0
+	for _, s := range scheduledContent {
+		var ids []uint
+		err := db.Model(s.model(0)).
+			Where("publish_status = ? AND publish_at <= ?", workflow.Scheduled, time.Now()).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return published, err
+		}
+
+		for _, id := range ids {
+			t := PublishTransition{
+				ContentType: s.contentType,
+				ContentId:   id,
+				FromStatus:  uint(workflow.Scheduled),
+				ToStatus:    uint(workflow.Published),
+			}
+			updates := map[string]interface{}{"publish_time": gorm.Expr("publish_at"), "publish_at": nil}
+			err := ChangePublishStatus(s.model(id), &t, updates)
+			if errors.Is(err, ErrPublishStatusChanged) {
+				continue
+			}
+			if err != nil {
+				return published, err
+			}
+			published++
+		}
+	}
+	return published, nil
+}
+
+func ListPublishTransitions(contentType string, contentId uint) ([]PublishTransition, error) {
+	var transitions []PublishTransition
+	err := db.Preload("Actor").
+		Where("content_type = ? AND content_id = ?", contentType, contentId).
+		Order("id asc").
+		Find(&transitions).Error
+	return transitions, err
+}
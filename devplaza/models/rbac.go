@@ -0,0 +1,338 @@
+package models
+
+import (
+	"errors"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNameTaken  = errors.New("name already exists")
+	ErrRoleInUse  = errors.New("role is assigned to users")
+	ErrPermission = errors.New("permission not found")
+	ErrRole       = errors.New("role not found")
+)
+
+// RbacManagePermission 管理角色、权限组和权限
+const RbacManagePermission = "rbac:manage"
+
+// 权限
+
+func ListPermissions() ([]Permission, error) {
+	var perms []Permission
+	err := db.Order("name asc").Find(&perms).Error
+	return perms, err
+}
+
+func (p *Permission) Create() error {
+	if nameTaken(&Permission{}, p.Name, 0) {
+		return ErrNameTaken
+	}
+	return db.Create(p).Error
+}
+
+// findPermissions 按 ID 查询权限，有任何一个不存在即返回错误
+func findPermissions(ids []uint) ([]Permission, error) {
+	var perms []Permission
+	if err := db.Where("id IN ?", ids).Find(&perms).Error; err != nil {
+		return nil, err
+	}
+	if len(perms) != len(uniqueIds(ids)) {
+		return nil, ErrPermission
+	}
+	return perms, nil
+}
+
+// 角色
+
+func ListRoles() ([]Role, error) {
+	var roles []Role
+	err := db.Preload("Permissions").
+		Preload("PermissionGroups").
+		Order("id asc").
+		Find(&roles).Error
+	return roles, err
+}
+
+func (r *Role) GetByID(id uint) error {
+	return db.Preload("Permissions").
+		Preload("PermissionGroups.Permissions").
+		First(r, id).Error
+}
+
+func (r *Role) Create() error {
+	if nameTaken(&Role{}, r.Name, 0) {
+		return ErrNameTaken
+	}
+	return db.Create(r).Error
+}
+
+func (r *Role) Update() error {
+	if nameTaken(&Role{}, r.Name, r.ID) {
+		return ErrNameTaken
+	}
+	return db.Model(r).Select("name", "description").Updates(r).Error
+}
+
+// Delete 删除角色，仍有用户使用该角色时拒绝删除
+func (r *Role) Delete() error {
+	var count int64
+	if err := db.Table("user_roles").Where("role_id = ?", r.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrRoleInUse
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(r).Association("Permissions").Clear(); err != nil {
+			return err
+		}
+		if err := tx.Model(r).Association("PermissionGroups").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(r).Error
+	})
+}
+
+func AttachRolePermissions(r *Role, permissionIds []uint) error {
+	perms, err := findPermissions(permissionIds)
+	if err != nil {
+		return err
+	}
+	if err := db.Model(r).Association("Permissions").Append(&perms); err != nil {
+		return err
+	}
+	InvalidateAllPermissions()
+	return nil
+}
+
+func DetachRolePermission(r *Role, permissionId uint) error {
+	if err := db.Model(r).Association("Permissions").Delete(&Permission{Model: gorm.Model{ID: permissionId}}); err != nil {
+		return err
+	}
+	InvalidateAllPermissions()
+	return nil
+}
+
+func AttachRoleGroups(r *Role, groupIds []uint) error {
+	var groups []PermissionGroup
+	if err := db.Where("id IN ?", groupIds).Find(&groups).Error; err != nil {
+		return err
+	}
+	if len(groups) != len(uniqueIds(groupIds)) {
+		return gorm.ErrRecordNotFound
+	}
+	if err := db.Model(r).Association("PermissionGroups").Append(&groups); err != nil {
+		return err
+	}
+	InvalidateAllPermissions()
+	return nil
+}
+
+func DetachRoleGroup(r *Role, groupId uint) error {
+	if err := db.Model(r).Association("PermissionGroups").Delete(&PermissionGroup{Model: gorm.Model{ID: groupId}}); err != nil {
+		return err
+	}
+	InvalidateAllPermissions()
+	return nil
+}
+
+// 权限组
+
+func ListPermissionGroups() ([]PermissionGroup, error) {
+	var groups []PermissionGroup
+	err := db.Preload("Permissions").Order("id asc").Find(&groups).Error
+	return groups, err
+}
+
+func (g *PermissionGroup) GetByID(id uint) error {
+	return db.Preload("Permissions").First(g, id).Error
+}
+
+func (g *PermissionGroup) Create() error {
+	if nameTaken(&PermissionGroup{}, g.Name, 0) {
+		return ErrNameTaken
+	}
+	return db.Create(g).Error
+}
+
+func (g *PermissionGroup) Update() error {
+	if nameTaken(&PermissionGroup{}, g.Name, g.ID) {
+		return ErrNameTaken
+	}
+	return db.Model(g).Select("name", "description").Updates(g).Error
+}
+
+// Delete 删除权限组，同时解除与角色的关联
+func (g *PermissionGroup) Delete() error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM role_permission_groups WHERE permission_group_id = ?", g.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(g).Association("Permissions").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(g).Error
+	})
+	if err != nil {
+		return err
+	}
+	InvalidateAllPermissions()
+	return nil
+}
+
+func AttachGroupPermissions(g *PermissionGroup, permissionIds []uint) error {
+	perms, err := findPermissions(permissionIds)
+	if err != nil {
+		return err
+	}
+	if err := db.Model(g).Association("Permissions").Append(&perms); err != nil {
+		return err
+	}
+	InvalidateAllPermissions()
+	return nil
+}
+
+func DetachGroupPermission(g *PermissionGroup, permissionId uint) error {
+	if err := db.Model(g).Association("Permissions").Delete(&Permission{Model: gorm.Model{ID: permissionId}}); err != nil {
+		return err
+	}
+	InvalidateAllPermissions()
+	return nil
+}
+
+// 用户角色
+
+// findRoles 按 ID 查询角色，有任何一个不存在即返回错误
+func findRoles(ids []uint) ([]Role, error) {
+	var roles []Role
+	if err := db.Where("id IN ?", ids).Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	if len(roles) != len(uniqueIds(ids)) {
+		return nil, ErrRole
+	}
+	return roles, nil
+}
+
+func ListUserRoles(userId uint) ([]Role, error) {
+	var user User
+	if err := db.Preload("Roles").First(&user, userId).Error; err != nil {
+		return nil, err
+	}
+	return user.Roles, nil
+}
+
+// GetUserRoleNames 用户角色名，写入访问令牌
+func GetUserRoleNames(userId uint) ([]string, error) {
+	names := []string{}
+	err := db.Table("roles").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ? AND roles.deleted_at IS NULL", userId).
+		Order("roles.name asc").
+		Pluck("roles.name", &names).Error
+	return names, err
+}
+
+// SetUserRoles 替换用户的全部角色，roleIds 为空表示移除所有角色
+func SetUserRoles(userId uint, roleIds []uint) error {
+	roles := []Role{}
+	if len(roleIds) > 0 {
+		var err error
+		if roles, err = findRoles(roleIds); err != nil {
+			return err
+		}
+	}
+
+	var user User
+	if err := db.First(&user, userId).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&user).Association("Roles").Replace(roles); err != nil {
+		return err
+	}
+	InvalidateUserPermissions(userId)
+	return nil
+}
+
+func AddUserRoles(userId uint, roleIds []uint) error {
+	roles, err := findRoles(roleIds)
+	if err != nil {
+		return err
+	}
+
+	var user User
+	if err := db.First(&user, userId).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&user).Association("Roles").Append(roles); err != nil {
+		return err
+	}
+	InvalidateUserPermissions(userId)
+	return nil
+}
+
+func RemoveUserRole(userId, roleId uint) error {
+	res := db.Exec("DELETE FROM user_roles WHERE user_id = ? AND role_id = ?", userId, roleId)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	InvalidateUserPermissions(userId)
+	return nil
+}
+
+// QueryUsersByRole 分页查询拥有某个角色的用户
+func QueryUsersByRole(roleId uint, page, pageSize int) ([]User, int64, error) {
+	var users []User
+	var total int64
+
+	query := db.Model(&User{}).
+		Joins("JOIN user_roles ON user_roles.user_id = users.id").
+		Where("user_roles.role_id = ?", roleId)
+	query.Count(&total)
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	offset := (page - 1) * pageSize
+
+	err := query.Order("users.id asc").Limit(pageSize).Offset(offset).Find(&users).Error
+	return users, total, err
+}
+
+// MigrateUserRoles 将旧的单一角色（users.role_id）复制到 user_roles，只执行一次，
+// 之后管理员移除的角色不会再被加回；role_id 保持不变，删除该列需要单独的迁移
+func MigrateUserRoles() {
+	err := runOnce("copy_user_roles", func(tx *gorm.DB) error {
+		return tx.Exec(`
+			INSERT INTO user_roles (user_id, role_id)
+			SELECT id, role_id FROM users WHERE role_id <> 0
+			ON CONFLICT DO NOTHING
+		`).Error
+	})
+	if err != nil {
+		log.Println("Migrate user roles failed:", err)
+	}
+}
+
+func nameTaken(model interface{}, name string, excludeId uint) bool {
+	var count int64
+	db.Model(model).Where("name = ? AND id <> ?", name, excludeId).Count(&count)
+	return count > 0
+}
+
+func uniqueIds(ids []uint) map[uint]struct{} {
+	set := make(map[uint]struct{}, len(ids))
+	for _, id := range ids {
+		set[id] = struct{}{}
+	}
+	return set
+}
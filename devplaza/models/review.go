@@ -0,0 +1,298 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrReviewAssigned       = errors.New("review already assigned")
+	ErrInvalidParentComment = errors.New("invalid parent comment")
+)
+
+// ReviewAssignment 待审核内容的负责人，每条内容最多一位
+type ReviewAssignment struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	ContentType string    `gorm:"uniqueIndex:idx_review_assignment;not null" json:"content_type"`
+	ContentId   uint      `gorm:"uniqueIndex:idx_review_assignment;not null" json:"content_id"`
+	ReviewerId  uint      `gorm:"index;not null" json:"reviewer_id"`
+	Reviewer    *User     `gorm:"foreignKey:ReviewerId" json:"reviewer,omitempty"`
+	AssignedBy  uint      `json:"assigned_by"` // 认领时为审核员本人
+}
+
+// ReviewComment 审核意见，审核员和作者可以在同一内容下回复形成讨论
+type ReviewComment struct {
+	gorm.Model
+	ContentType string          `gorm:"index:idx_review_comment_content;not null" json:"content_type"`
+	ContentId   uint            `gorm:"index:idx_review_comment_content;not null" json:"content_id"`
+	UserId      uint            `json:"user_id"`
+	User        *User           `gorm:"foreignKey:UserId" json:"user"`
+	ParentId    *uint           `gorm:"index" json:"parent_id"`
+	Version     int             `json:"version"` // 评论针对的修订版本，活动没有修订版本时为 0
+	Anchor      string          `json:"anchor"`  // 行内评论的位置，如 L12 或引用的原文
+	Body        string          `gorm:"type:text;not null" json:"body"`
+	Replies     []ReviewComment `gorm:"-" json:"replies"`
+}
+
+// ReviewQueueItem 审核队列中的一条内容
+type ReviewQueueItem struct {
+	ContentType   string     `json:"content_type"`
+	ContentId     uint       `json:"content_id"`
+	Title         string     `json:"title"`
+	PublishStatus uint       `json:"publish_status"`
+	PublishAt     *time.Time `json:"publish_at"`
+	SubmittedAt   time.Time  `json:"submitted_at"` // 进入当前状态的时间
+	Age           int64      `gorm:"-" json:"age"` // 提交至今的秒数
+	SubmitterId   uint       `json:"submitter_id"`
+	SubmitterName string     `json:"submitter_name"`
+	AssigneeId    *uint      `json:"assignee_id"`
+	AssigneeName  *string    `json:"assignee_name"`
+}
+
+// ReviewQueueScope 用户可审核的一类内容，All 为 false 时只包含所属 Dapp 或分类（含子分类）在范围内的内容
+type ReviewQueueScope struct {
+	ContentType string
+	All         bool
+	DappIds     []uint
+	CategoryIds []uint
+}
+
+type ReviewQueueFilter struct {
+	Scopes      []ReviewQueueScope
+	ContentType string
+	AssigneeId  uint
+	Unassigned  bool
+	Statuses    []uint
+	Page        int // 当前页码，从 1 开始
+	PageSize    int // 每页数量
+}
+
+// categorySubtree 分类及其所有子分类
+const categorySubtree = `WITH RECURSIVE sub AS (
+	SELECT id FROM categories WHERE id IN ?
+	UNION SELECT cc.id FROM categories cc JOIN sub ON cc.parent_id = sub.id
+) SELECT id FROM sub`
+
+// reviewQueueSources 各类内容的表、标题和提交人字段；
+// byDapp、byCategory 为按 Dapp、分类限定审核范围时的条件，参数分别为 Dapp ID 和分类 ID，
+// 都为空表示不支持限定范围
+var reviewQueueSources = map[string]struct{ table, title, submitter, byDapp, byCategory string }{
+	// 博客的分类是自由填写的名称，按分类名或全名匹配
+	ContentBlog: {"articles", "title", "publisher_id", "", `EXISTS (
+		SELECT 1 FROM categories cat WHERE cat.deleted_at IS NULL
+		AND (LOWER(cat.name) = LOWER(c.category) OR LOWER(cat.full_name) = LOWER(c.category))
+		AND cat.id IN (` + categorySubtree + `))`},
+	ContentEvent: {"events", "title", "user_id", "", ""},
+	ContentTutorial: {"tutorials", "title", "publisher_id", "c.dapp_id IN ?", `c.dapp_id IN (
+		SELECT d.id FROM dapps d WHERE d.deleted_at IS NULL AND d.category_id IN (` + categorySubtree + `))`},
+	ContentDapp: {"dapps", "name", "user_id", "c.id IN ?", "c.category_id IN (" + categorySubtree + ")"},
+}
+
+// QueryReviewQueue 按提交时间从早到晚列出各类待审核内容
+func QueryReviewQueue(filter ReviewQueueFilter) ([]ReviewQueueItem, int64, error) {
+	var parts []string
+	var args []interface{}
+	for _, scope := range filter.Scopes {
+		source, ok := reviewQueueSources[scope.ContentType]
+		if !ok || (filter.ContentType != "" && filter.ContentType != scope.ContentType) {
+			continue
+		}
+		if !scope.All && source.byDapp == "" && source.byCategory == "" {
+			continue
+		}
+		// Dapp 没有定时发布
+		publishAt := "c.publish_at"
+		if scope.ContentType == ContentDapp {
+			publishAt = "NULL::timestamptz"
+		}
+		part := `SELECT ? AS content_type, c.id AS content_id, c.` + source.title + ` AS title, c.publish_status,
+			` + publishAt + ` AS publish_at, c.` + source.submitter + ` AS submitter_id, c.created_at
+			FROM ` + source.table + ` c WHERE c.deleted_at IS NULL AND c.publish_status IN ?`
+		args = append(args, scope.ContentType, filter.Statuses)
+
+		if !scope.All {
+			var conds []string
+			if source.byDapp != "" {
+				conds = append(conds, source.byDapp)
+				args = append(args, nonEmptyIds(scope.DappIds))
+			}
+			if source.byCategory != "" {
+				conds = append(conds, source.byCategory)
+				args = append(args, nonEmptyIds(scope.CategoryIds))
+			}
+			part += " AND (" + strings.Join(conds, " OR ") + ")"
+		}
+		parts = append(parts, part)
+	}
+	if len(parts) == 0 {
+		return nil, 0, nil
+	}
+
+	from := `FROM (` + strings.Join(parts, " UNION ALL ") + `) q
+		LEFT JOIN users u ON u.id = q.submitter_id
+		LEFT JOIN review_assignments ra ON ra.content_type = q.content_type AND ra.content_id = q.content_id
+		LEFT JOIN users r ON r.id = ra.reviewer_id
+		WHERE 1 = 1`
+	if filter.AssigneeId != 0 {
+		from += ` AND ra.reviewer_id = ?`
+		args = append(args, filter.AssigneeId)
+	}
+	if filter.Unassigned {
+		from += ` AND ra.id IS NULL`
+	}
+
+	var total int64
+	if err := db.Raw(`SELECT COUNT(*) `+from, args...).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = 20
+	}
+	offset := (filter.Page - 1) * filter.PageSize
+
+	var items []ReviewQueueItem
+	err := db.Raw(`SELECT q.content_type, q.content_id, q.title, q.publish_status, q.publish_at, q.submitter_id,
+			COALESCE((SELECT MAX(t.created_at) FROM publish_transitions t
+				WHERE t.content_type = q.content_type AND t.content_id = q.content_id AND t.to_status = q.publish_status),
+				q.created_at) AS submitted_at,
+			u.username AS submitter_name, ra.reviewer_id AS assignee_id, r.username AS assignee_name `+
+		from+` ORDER BY submitted_at ASC, q.content_id ASC LIMIT ? OFFSET ?`,
+		append(args, filter.PageSize, offset)...).Scan(&items).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	now := time.Now()
+	for i := range items {
+		items[i].Age = int64(now.Sub(items[i].SubmittedAt).Seconds())
+	}
+	return items, total, nil
+}
+
+// nonEmptyIds IN 条件不能为空列表，用不存在的 0 代替
+func nonEmptyIds(ids []uint) []uint {
+	if len(ids) == 0 {
+		return []uint{0}
+	}
+	return ids
+}
+
+func GetReviewAssignment(contentType string, contentId uint) (*ReviewAssignment, error) {
+	var a ReviewAssignment
+	err := db.Preload("Reviewer").
+		Where("content_type = ? AND content_id = ?", contentType, contentId).
+		First(&a).Error
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// ClaimReview 认领审核，已由其他审核员负责时返回 ErrReviewAssigned
+func ClaimReview(contentType string, contentId, reviewerId uint) (*ReviewAssignment, error) {
+	a := ReviewAssignment{ContentType: contentType, ContentId: contentId, ReviewerId: reviewerId, AssignedBy: reviewerId}
+	res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&a)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		existing, err := GetReviewAssignment(contentType, contentId)
+		if err != nil {
+			return nil, err
+		}
+		if existing.ReviewerId != reviewerId {
+			return nil, ErrReviewAssigned
+		}
+		return existing, nil
+	}
+	return GetReviewAssignment(contentType, contentId)
+}
+
+// AssignReview 指派审核员，覆盖原有的负责人
+func AssignReview(contentType string, contentId, reviewerId, assignedBy uint) (*ReviewAssignment, error) {
+	a := ReviewAssignment{ContentType: contentType, ContentId: contentId, ReviewerId: reviewerId, AssignedBy: assignedBy}
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "content_type"}, {Name: "content_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"reviewer_id", "assigned_by", "updated_at"}),
+	}).Create(&a).Error
+	if err != nil {
+		return nil, err
+	}
+	return GetReviewAssignment(contentType, contentId)
+}
+
+func UnassignReview(contentType string, contentId uint) error {
+	return db.Where("content_type = ? AND content_id = ?", contentType, contentId).
+		Delete(&ReviewAssignment{}).Error
+}
+
+// Create 发表审核意见，回复时父评论必须属于同一内容
+func (rc *ReviewComment) Create() error {
+	if rc.ParentId != nil {
+		var parent ReviewComment
+		if err := db.First(&parent, *rc.ParentId).Error; err != nil {
+			return ErrInvalidParentComment
+		}
+		if parent.ContentType != rc.ContentType || parent.ContentId != rc.ContentId {
+			return ErrInvalidParentComment
+		}
+	}
+	if err := db.Create(rc).Error; err != nil {
+		return err
+	}
+	return db.Preload("User").First(rc, rc.ID).Error
+}
+
+// ListReviewComments 内容的审核意见，按发表时间排序并组织成回复树
+func ListReviewComments(contentType string, contentId uint) ([]ReviewComment, error) {
+	var comments []ReviewComment
+	err := db.Preload("User").
+		Where("content_type = ? AND content_id = ?", contentType, contentId).
+		Order("id asc").
+		Find(&comments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[uint][]uint)
+	var roots []uint
+	index := make(map[uint]int, len(comments))
+	for i, c := range comments {
+		index[c.ID] = i
+	}
+	for _, c := range comments {
+		// 父评论被删除时作为顶层评论展示
+		if c.ParentId != nil {
+			if _, ok := index[*c.ParentId]; ok {
+				children[*c.ParentId] = append(children[*c.ParentId], c.ID)
+				continue
+			}
+		}
+		roots = append(roots, c.ID)
+	}
+
+	var build func(id uint) ReviewComment
+	build = func(id uint) ReviewComment {
+		c := comments[index[id]]
+		c.Replies = []ReviewComment{}
+		for _, child := range children[id] {
+			c.Replies = append(c.Replies, build(child))
+		}
+		return c
+	}
+	tree := make([]ReviewComment, 0, len(roots))
+	for _, id := range roots {
+		tree = append(tree, build(id))
+	}
+	return tree, nil
+}
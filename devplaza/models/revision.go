@@ -0,0 +1,138 @@
+package models
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// Revision 博客、教程每次保存的完整版本，只增不改
+type Revision struct {
+	ID          uint       `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ContentType string     `gorm:"uniqueIndex:idx_revision_version;not null" json:"content_type"`
+	ContentId   uint       `gorm:"uniqueIndex:idx_revision_version;not null" json:"content_id"`
+	Version     int        `gorm:"uniqueIndex:idx_revision_version;not null" json:"version"` // 从 1 开始递增
+	AuthorId    uint       `json:"author_id"`
+	Author      *User      `gorm:"foreignKey:AuthorId" json:"author,omitempty"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Content     string     `gorm:"type:text" json:"content,omitempty"`
+	ApprovedAt  *time.Time `json:"approved_at"` // 审核通过发布时的版本
+}
+
+// saveWithRevision 在事务中保存内容并追加一个修订版本。
+// 更新内容时会锁住该行，同一内容的并发保存按顺序分配版本号
+func saveWithRevision(content interface{}, revision func() *Revision) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(content).Error; err != nil {
+			return err
+		}
+		r := revision()
+		var latest int
+		if err := tx.Model(&Revision{}).
+			Where("content_type = ? AND content_id = ?", r.ContentType, r.ContentId).
+			Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+			return err
+		}
+		r.Version = latest + 1
+		return tx.Create(r).Error
+	})
+}
+
+// SaveWithRevision 处理正文 Markdown 后保存博客，并记录修订版本
+func (a *Article) SaveWithRevision(authorId uint) error {
+	a.RenderedContent = renderContent(a.Content)
+	return saveWithRevision(a, func() *Revision {
+		return &Revision{
+			ContentType: ContentBlog,
+			ContentId:   a.ID,
+			AuthorId:    authorId,
+			Title:       a.Title,
+			Description: a.Description,
+			Content:     a.Content,
+		}
+	})
+}
+
+// SaveWithRevision 处理正文 Markdown 后保存教程，并记录修订版本
+func (t *Tutorial) SaveWithRevision(authorId uint) error {
+	t.RenderedContent = renderContent(t.Content)
+	return saveWithRevision(t, func() *Revision {
+		return &Revision{
+			ContentType: ContentTutorial,
+			ContentId:   t.ID,
+			AuthorId:    authorId,
+			Title:       t.Title,
+			Description: t.Description,
+			Content:     t.Content,
+		}
+	})
+}
+
+// ListRevisions 内容的所有修订版本，新版本在前，不含正文
+func ListRevisions(contentType string, contentId uint) ([]Revision, error) {
+	var revisions []Revision
+	err := db.Preload("Author").
+		Omit("content").
+		Where("content_type = ? AND content_id = ?", contentType, contentId).
+		Order("version desc").
+		Find(&revisions).Error
+	return revisions, err
+}
+
+// GetRevision 获取指定版本，version 为 0 时返回最新版本
+func GetRevision(contentType string, contentId uint, version int) (*Revision, error) {
+	var r Revision
+	query := db.Preload("Author").Where("content_type = ? AND content_id = ?", contentType, contentId)
+	if version != 0 {
+		query = query.Where("version = ?", version)
+	}
+	err := query.Order("version desc").First(&r).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRevisionNotFound
+	}
+	return &r, err
+}
+
+// GetApprovedRevision 最近一次审核通过发布的版本
+func GetApprovedRevision(contentType string, contentId uint) (*Revision, error) {
+	var r Revision
+	err := db.Preload("Author").
+		Where("content_type = ? AND content_id = ? AND approved_at IS NOT NULL", contentType, contentId).
+		Order("version desc").
+		First(&r).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRevisionNotFound
+	}
+	return &r, err
+}
+
+// markRevisionApproved 将内容的最新版本标记为审核通过的版本
+func markRevisionApproved(tx *gorm.DB, contentType string, contentId uint, at time.Time) error {
+	return tx.Exec(`
+		UPDATE revisions SET approved_at = ?
+		WHERE id = (SELECT MAX(id) FROM revisions WHERE content_type = ? AND content_id = ?)
+	`, at, contentType, contentId).Error
+}
+
+// MigrateRevisions 为还没有修订记录的博客、教程生成第一个版本，已发布的视为审核通过的版本
+func MigrateRevisions() {
+	for contentType, table := range map[string]string{ContentBlog: "articles", ContentTutorial: "tutorials"} {
+		err := db.Exec(`
+			INSERT INTO revisions (created_at, content_type, content_id, version, author_id, title, description, content, approved_at)
+			SELECT c.updated_at, ?, c.id, 1, c.publisher_id, c.title, c.description, c.content,
+				CASE WHEN c.publish_status = 2 THEN COALESCE(c.publish_time, c.updated_at) END
+			FROM `+table+` c
+			WHERE c.deleted_at IS NULL
+				AND NOT EXISTS (SELECT 1 FROM revisions r WHERE r.content_type = ? AND r.content_id = c.id)
+		`, contentType, contentType).Error
+		if err != nil {
+			log.Printf("Migrate %s revisions failed: %v", contentType, err)
+		}
+	}
+}
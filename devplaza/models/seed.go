@@ -0,0 +1,371 @@
+package models
+
+import (
+	_ "embed"
+	"fmt"
+	"log"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+//go:embed rbac.yaml
+var rbacManifest []byte
+
+// RbacManifest 声明式的角色与权限清单
+type RbacManifest struct {
+	Permissions []struct {
+		Name        string `yaml:"name"`
+		Description string `yaml:"description"`
+	} `yaml:"permissions"`
+	Groups []struct {
+		Name        string   `yaml:"name"`
+		Description string   `yaml:"description"`
+		Permissions []string `yaml:"permissions"`
+	} `yaml:"groups"`
+	Roles []struct {
+		Name        string   `yaml:"name"`
+		Description string   `yaml:"description"`
+		Groups      []string `yaml:"groups"`
+		Permissions []string `yaml:"permissions"`
+	} `yaml:"roles"`
+}
+
+// RbacSeed 记录清单中的关联（权限组的权限、角色的权限和权限组）已经补齐过，
+// 之后管理员通过接口移除该关联时不会在启动时再被加回
+type RbacSeed struct {
+	ID     uint   `gorm:"primarykey"`
+	Kind   string `gorm:"uniqueIndex:idx_rbac_seed;not null"` // group_permission、role_permission、role_group
+	Owner  string `gorm:"uniqueIndex:idx_rbac_seed;not null"` // 权限组或角色名
+	Member string `gorm:"uniqueIndex:idx_rbac_seed;not null"` // 权限或权限组名
+}
+
+const (
+	seedGroupPermission = "group_permission"
+	seedRolePermission  = "role_permission"
+	seedRoleGroup       = "role_group"
+)
+
+// rbacSeeds 已补齐过的关联
+type rbacSeeds map[RbacSeed]bool
+
+func loadRbacSeeds(tx *gorm.DB) (rbacSeeds, error) {
+	var rows []RbacSeed
+	if err := tx.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	seeds := rbacSeeds{}
+	for _, r := range rows {
+		seeds[RbacSeed{Kind: r.Kind, Owner: r.Owner, Member: r.Member}] = true
+	}
+	return seeds, nil
+}
+
+// mark 记录关联已补齐，已记录过时不做任何事
+func (s rbacSeeds) mark(tx *gorm.DB, kind, owner, member string) error {
+	key := RbacSeed{Kind: kind, Owner: owner, Member: member}
+	if s[key] {
+		return nil
+	}
+	if err := tx.Create(&key).Error; err != nil {
+		return err
+	}
+	s[RbacSeed{Kind: kind, Owner: owner, Member: member}] = true
+	return nil
+}
+
+// forget 权限组或角色被重新创建时，清除它以前的补齐记录，清单中的关联重新补齐
+func (s rbacSeeds) forget(tx *gorm.DB, owner string, kinds ...string) error {
+	if err := tx.Where("owner = ? AND kind IN ?", owner, kinds).Delete(&RbacSeed{}).Error; err != nil {
+		return err
+	}
+	for _, kind := range kinds {
+		for key := range s {
+			if key.Owner == owner && key.Kind == kind {
+				delete(s, key)
+			}
+		}
+	}
+	return nil
+}
+
+// loadRbacManifest 解析内嵌清单并检查引用是否都已声明
+func loadRbacManifest() (*RbacManifest, error) {
+	var m RbacManifest
+	if err := yaml.Unmarshal(rbacManifest, &m); err != nil {
+		return nil, err
+	}
+
+	perms := map[string]bool{}
+	for _, p := range m.Permissions {
+		perms[p.Name] = true
+	}
+	groups := map[string]bool{}
+	for _, g := range m.Groups {
+		groups[g.Name] = true
+		for _, p := range g.Permissions {
+			if !perms[p] {
+				return nil, fmt.Errorf("group %s references undeclared permission %s", g.Name, p)
+			}
+		}
+	}
+	for _, r := range m.Roles {
+		for _, g := range r.Groups {
+			if !groups[g] {
+				return nil, fmt.Errorf("role %s references undeclared group %s", r.Name, g)
+			}
+		}
+		for _, p := range r.Permissions {
+			if !perms[p] {
+				return nil, fmt.Errorf("role %s references undeclared permission %s", r.Name, p)
+			}
+		}
+	}
+	return &m, nil
+}
+
+// ReconcileRolesAndPermissions 按清单补齐权限、权限组、角色及其关联，可重复执行；
+// 每个关联只补齐一次，之后被管理员移除的关联不再加回，被管理员删除的权限、权限组、角色也不再重新创建；
+// 数据库与清单不一致的地方（描述不同、多出或被移除的记录、关联）只记录日志，不做修改
+func ReconcileRolesAndPermissions() error {
+	m, err := loadRbacManifest()
+	if err != nil {
+		return err
+	}
+
+	var changed bool
+	err = db.Transaction(func(tx *gorm.DB) error {
+		seeds, err := loadRbacSeeds(tx)
+		if err != nil {
+			return err
+		}
+
+		// 删除后又通过管理接口建了同名记录时优先使用未删除的记录
+		// 1. 权限
+		perms := map[string]*Permission{}
+		for _, want := range m.Permissions {
+			var p Permission
+			res := tx.Unscoped().Where("name = ?", want.Name).Order("deleted_at IS NOT NULL").Limit(1).Find(&p)
+			if res.Error != nil {
+				return res.Error
+			}
+			if p.DeletedAt.Valid {
+				log.Printf("rbac drift: permission %s was deleted, not restoring", want.Name)
+				continue
+			}
+			if res.RowsAffected == 0 {
+				p = Permission{Name: want.Name, Description: want.Description}
+				if err := tx.Create(&p).Error; err != nil {
+					return err
+				}
+				log.Printf("rbac: created permission %s", want.Name)
+				changed = true
+			} else if p.Description != want.Description {
+				log.Printf("rbac drift: permission %s description is %q, manifest has %q", want.Name, p.Description, want.Description)
+			}
+			perms[want.Name] = &p
+		}
+
+		// 2. 权限组
+		groups := map[string]*PermissionGroup{}
+		for _, want := range m.Groups {
+			var g PermissionGroup
+			res := tx.Unscoped().Where("name = ?", want.Name).Order("deleted_at IS NOT NULL").Limit(1).Find(&g)
+			if res.Error != nil {
+				return res.Error
+			}
+			if g.DeletedAt.Valid {
+				log.Printf("rbac drift: permission group %s was deleted, not restoring", want.Name)
+				continue
+			}
+			if res.RowsAffected > 0 {
+				if err := tx.Model(&g).Association("Permissions").Find(&g.Permissions); err != nil {
+					return err
+				}
+			}
+			if res.RowsAffected == 0 {
+				g = PermissionGroup{Name: want.Name, Description: want.Description}
+				if err := tx.Create(&g).Error; err != nil {
+					return err
+				}
+				log.Printf("rbac: created permission group %s", want.Name)
+				changed = true
+				if err := seeds.forget(tx, g.Name, seedGroupPermission); err != nil {
+					return err
+				}
+			} else if g.Description != want.Description {
+				log.Printf("rbac drift: permission group %s description is %q, manifest has %q", want.Name, g.Description, want.Description)
+			}
+
+			added, err := reconcilePermissions(tx, seeds, seedGroupPermission, &g, g.Name, "permission group "+g.Name, g.Permissions, want.Permissions, perms)
+			if err != nil {
+				return err
+			}
+			changed = changed || added
+			groups[want.Name] = &g
+		}
+
+		// 3. 角色
+		for _, want := range m.Roles {
+			var r Role
+			res := tx.Unscoped().Where("name = ?", want.Name).Order("deleted_at IS NOT NULL").Limit(1).Find(&r)
+			if res.Error != nil {
+				return res.Error
+			}
+			if r.DeletedAt.Valid {
+				log.Printf("rbac drift: role %s was deleted, not restoring", want.Name)
+				continue
+			}
+			if res.RowsAffected > 0 {
+				if err := tx.Model(&r).Association("Permissions").Find(&r.Permissions); err != nil {
+					return err
+				}
+				if err := tx.Model(&r).Association("PermissionGroups").Find(&r.PermissionGroups); err != nil {
+					return err
+				}
+			}
+			if res.RowsAffected == 0 {
+				r = Role{Name: want.Name, Description: want.Description}
+				if err := tx.Create(&r).Error; err != nil {
+					return err
+				}
+				log.Printf("rbac: created role %s", want.Name)
+				changed = true
+				if err := seeds.forget(tx, r.Name, seedRolePermission, seedRoleGroup); err != nil {
+					return err
+				}
+			} else if r.Description != want.Description {
+				log.Printf("rbac drift: role %s description is %q, manifest has %q", want.Name, r.Description, want.Description)
+			}
+
+			added, err := reconcilePermissions(tx, seeds, seedRolePermission, &r, r.Name, "role "+r.Name, r.Permissions, want.Permissions, perms)
+			if err != nil {
+				return err
+			}
+			changed = changed || added
+
+			have := map[string]bool{}
+			for _, g := range r.PermissionGroups {
+				have[g.Name] = true
+			}
+			wantSet := map[string]bool{}
+			for _, name := range want.Groups {
+				wantSet[name] = true
+				if groups[name] == nil {
+					// 权限组已被删除
+					continue
+				}
+				seeded := seeds[RbacSeed{Kind: seedRoleGroup, Owner: r.Name, Member: name}]
+				if !have[name] && seeded {
+					log.Printf("rbac drift: role %s group %s was removed, not restoring", r.Name, name)
+					continue
+				}
+				if !have[name] {
+					if err := tx.Model(&r).Association("PermissionGroups").Append(groups[name]); err != nil {
+						return err
+					}
+					log.Printf("rbac: added group %s to role %s", name, r.Name)
+					changed = true
+				}
+				if err := seeds.mark(tx, seedRoleGroup, r.Name, name); err != nil {
+					return err
+				}
+			}
+			for name := range have {
+				if !wantSet[name] {
+					log.Printf("rbac drift: role %s has group %s not in manifest", r.Name, name)
+				}
+			}
+		}
+
+		return reportUnmanaged(tx, m)
+	})
+	if err != nil {
+		return err
+	}
+
+	if changed {
+		InvalidateAllPermissions()
+	}
+	return nil
+}
+
+// reconcilePermissions 为权限组或角色补齐清单中尚未补齐过的直接权限，报告多出或被移除的权限
+func reconcilePermissions(tx *gorm.DB, seeds rbacSeeds, kind string, owner interface{}, ownerName, label string, current []Permission, want []string, perms map[string]*Permission) (bool, error) {
+	have := map[string]bool{}
+	for _, p := range current {
+		have[p.Name] = true
+	}
+
+	var added bool
+	wantSet := map[string]bool{}
+	for _, name := range want {
+		wantSet[name] = true
+		if perms[name] == nil {
+			// 权限已被删除
+			continue
+		}
+		seeded := seeds[RbacSeed{Kind: kind, Owner: ownerName, Member: name}]
+		if !have[name] && seeded {
+			log.Printf("rbac drift: %s permission %s was removed, not restoring", label, name)
+			continue
+		}
+		if !have[name] {
+			if err := tx.Model(owner).Association("Permissions").Append(perms[name]); err != nil {
+				return false, err
+			}
+			log.Printf("rbac: added permission %s to %s", name, label)
+			added = true
+		}
+		if err := seeds.mark(tx, kind, ownerName, name); err != nil {
+			return false, err
+		}
+	}
+	for name := range have {
+		if !wantSet[name] {
+			log.Printf("rbac drift: %s has permission %s not in manifest", label, name)
+		}
+	}
+	return added, nil
+}
+
+// reportUnmanaged 报告数据库中存在但清单中没有的权限、权限组和角色
+func reportUnmanaged(tx *gorm.DB, m *RbacManifest) error {
+	var names []string
+
+	declared := make([]string, 0, len(m.Permissions))
+	for _, p := range m.Permissions {
+		declared = append(declared, p.Name)
+	}
+	if err := tx.Model(&Permission{}).Where("name NOT IN ?", declared).Pluck("name", &names).Error; err != nil {
+		return err
+	}
+	for _, name := range names {
+		log.Printf("rbac drift: permission %s not in manifest", name)
+	}
+
+	declared = declared[:0]
+	for _, g := range m.Groups {
+		declared = append(declared, g.Name)
+	}
+	names = nil
+	if err := tx.Model(&PermissionGroup{}).Where("name NOT IN ?", declared).Pluck("name", &names).Error; err != nil {
+		return err
+	}
+	for _, name := range names {
+		log.Printf("rbac drift: permission group %s not in manifest", name)
+	}
+
+	declared = declared[:0]
+	for _, r := range m.Roles {
+		declared = append(declared, r.Name)
+	}
+	names = nil
+	if err := tx.Model(&Role{}).Where("name NOT IN ?", declared).Pluck("name", &names).Error; err != nil {
+		return err
+	}
+	for _, name := range names {
+		log.Printf("rbac drift: role %s not in manifest", name)
+	}
+	return nil
+}
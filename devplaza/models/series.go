@@ -0,0 +1,217 @@
+package models
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrTutorialInSeries   = errors.New("tutorial already belongs to a series")
+	ErrInvalidSeriesOrder = errors.New("tutorial ids must match the tutorials in the series")
+)
+
+// Series 系列教程，按 SeriesPosition 排列章节
+type Series struct {
+	gorm.Model
+	Title       string     `gorm:"not null" json:"title"`
+	Description string     `gorm:"type:text" json:"description"`
+	CoverImg    string     `json:"cover_img"`
+	UserId      uint       `gorm:"index" json:"user_id"`
+	User        *User      `gorm:"foreignKey:UserId" json:"user"`
+	Tutorials   []Tutorial `gorm:"foreignKey:SeriesId" json:"tutorials"`
+}
+
+// SeriesChapter 系列中的一章，用于上一章、下一章导航
+type SeriesChapter struct {
+	Id             uint   `json:"id"`
+	Title          string `json:"title"`
+	SeriesPosition int    `json:"series_position"`
+}
+
+func (s *Series) Create() error {
+	return db.Create(s).Error
+}
+
+// GetByID 获取系列和按顺序排列的章节（不含正文）
+func (s *Series) GetByID(id uint) error {
+	return db.Preload("User").
+		Preload("Tutorials", func(tx *gorm.DB) *gorm.DB {
+			return tx.Omit("content", "content_html").Order("series_position asc, id asc")
+		}).
+		First(s, id).Error
+}
+
+func (s *Series) Update() error {
+	if s.ID == 0 {
+		return errors.New("missing Series ID")
+	}
+	return db.Omit("Tutorials", "User").Save(s).Error
+}
+
+// Delete 删除系列，其中的教程保留但不再属于任何系列
+func (s *Series) Delete() error {
+	if s.ID == 0 {
+		return errors.New("missing Series ID")
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Tutorial{}).Where("series_id = ?", s.ID).
+			UpdateColumns(map[string]interface{}{"series_id": nil, "series_position": 0}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(s).Error
+	})
+}
+
+type SeriesFilter struct {
+	Keyword  string // 标题或描述关键词
+	UserId   uint
+	Page     int // 当前页码，从 1 开始
+	PageSize int // 每页数量，建议默认 10
+}
+
+func QuerySeries(filter SeriesFilter) ([]Series, int64, error) {
+	var series []Series
+	var total int64
+
+	query := db.Preload("User").Model(&Series{})
+
+	if filter.Keyword != "" {
+		likePattern := "%" + filter.Keyword + "%"
+		query = query.Where("title ILIKE ? OR description ILIKE ?", likePattern, likePattern)
+	}
+
+	if filter.UserId != 0 {
+		query = query.Where("user_id = ?", filter.UserId)
+	}
+
+	// 统计总数（不加 limit 和 offset）
+	query.Count(&total)
+
+	// 分页
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 {
+		filter.PageSize = 10
+	}
+	offset := (filter.Page - 1) * filter.PageSize
+	query = query.Order("created_at desc").Offset(offset).Limit(filter.PageSize)
+
+	err := query.Find(&series).Error
+	return series, total, err
+}
+
+// AddSeriesTutorial 将教程加入系列末尾，已属于其他系列时返回 ErrTutorialInSeries
+func AddSeriesTutorial(seriesId, tutorialId uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		// 锁住系列，并发加入的教程按顺序排在末尾
+		var series Series
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&series, seriesId).Error; err != nil {
+			return err
+		}
+		var last int
+		if err := tx.Model(&Tutorial{}).Where("series_id = ?", seriesId).
+			Select("COALESCE(MAX(series_position), 0)").Scan(&last).Error; err != nil {
+			return err
+		}
+		res := tx.Model(&Tutorial{}).
+			Where("id = ? AND series_id IS NULL", tutorialId).
+			UpdateColumns(map[string]interface{}{"series_id": seriesId, "series_position": last + 1})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected > 0 {
+			return nil
+		}
+		// 已在该系列中时保持原来的位置
+		var count int64
+		if err := tx.Model(&Tutorial{}).Where("id = ? AND series_id = ?", tutorialId, seriesId).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrTutorialInSeries
+		}
+		return nil
+	})
+}
+
+// RemoveSeriesTutorial 将教程移出系列，后面的章节依次前移
+func RemoveSeriesTutorial(seriesId, tutorialId uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var position int
+		if err := tx.Model(&Tutorial{}).Where("id = ? AND series_id = ?", tutorialId, seriesId).
+			Select("series_position").Scan(&position).Error; err != nil {
+			return err
+		}
+		if position == 0 {
+			return nil
+		}
+		if err := tx.Model(&Tutorial{}).Where("id = ?", tutorialId).
+			UpdateColumns(map[string]interface{}{"series_id": nil, "series_position": 0}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Tutorial{}).Where("series_id = ? AND series_position > ?", seriesId, position).
+			UpdateColumn("series_position", gorm.Expr("series_position - 1")).Error
+	})
+}
+
+// ReorderSeries 按 tutorialIds 的顺序重新排列章节，必须包含系列中所有教程
+func ReorderSeries(seriesId uint, tutorialIds []uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var series Series
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&series, seriesId).Error; err != nil {
+			return err
+		}
+		var current []uint
+		if err := tx.Model(&Tutorial{}).Where("series_id = ?", seriesId).Pluck("id", &current).Error; err != nil {
+			return err
+		}
+		if len(current) != len(tutorialIds) {
+			return ErrInvalidSeriesOrder
+		}
+		members := make(map[uint]bool, len(current))
+		for _, id := range current {
+			members[id] = true
+		}
+		for _, id := range tutorialIds {
+			if !members[id] {
+				return ErrInvalidSeriesOrder
+			}
+			delete(members, id)
+		}
+
+		for i, id := range tutorialIds {
+			if err := tx.Model(&Tutorial{}).Where("id = ?", id).
+				UpdateColumn("series_position", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SeriesNeighbors 教程在系列中已发布的上一章和下一章，没有时为 nil
+func SeriesNeighbors(seriesId uint, position int) (prev, next *SeriesChapter, err error) {
+	neighbor := func(cond, order string) (*SeriesChapter, error) {
+		var chapters []SeriesChapter
+		err := db.Model(&Tutorial{}).Select("id, title, series_position").
+			Where("series_id = ? AND publish_status = ?", seriesId, 2).
+			Where(cond, position).
+			Order(order).Limit(1).
+			Find(&chapters).Error
+		if err != nil || len(chapters) == 0 {
+			return nil, err
+		}
+		return &chapters[0], nil
+	}
+	if prev, err = neighbor("series_position < ?", "series_position desc"); err != nil {
+		return nil, nil, err
+	}
+	if next, err = neighbor("series_position > ?", "series_position asc"); err != nil {
+		return nil, nil, err
+	}
+	return prev, next, nil
+}
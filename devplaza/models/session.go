@@ -0,0 +1,92 @@
+package models
+
+import (
+	"devplaza/utils"
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrSessionEnded = errors.New("session ended")
+
+// Session 登录会话（设备），与刷新令牌族一一对应
+type Session struct {
+	gorm.Model
+	UserId      uint       `gorm:"index;not null" json:"user_id"`
+	FamilyId    string     `gorm:"uniqueIndex;not null" json:"-"`
+	UserAgent   string     `json:"user_agent"`
+	Ip          string     `json:"ip"`
+	LastSeenAt  time.Time  `json:"last_seen_at"`
+	EndedAt     *time.Time `json:"ended_at"`
+	MfaVerified bool       `gorm:"default:false" json:"mfa_verified"` // 登录时是否通过了两步验证
+	Current     bool       `gorm:"-" json:"current"`                  // 是否为发起请求的会话
+}
+
+func (s *Session) Create() error {
+	return db.Create(s).Error
+}
+
+// GetActiveSession 按令牌族查找未结束的会话
+func GetActiveSession(familyId string) (*Session, error) {
+	var s Session
+	if err := db.Where("family_id = ?", familyId).First(&s).Error; err != nil {
+		return nil, err
+	}
+	if s.EndedAt != nil {
+		return nil, ErrSessionEnded
+	}
+	return &s, nil
+}
+
+// TouchSession 更新最近活跃时间和 IP，一分钟内最多写一次
+func TouchSession(s *Session, ip string) error {
+	now := time.Now()
+	if now.Sub(s.LastSeenAt) < time.Minute && s.Ip == ip {
+		return nil
+	}
+	return db.Model(s).UpdateColumns(map[string]interface{}{
+		"last_seen_at": now,
+		"ip":           ip,
+	}).Error
+}
+
+// MarkSessionMfaVerified 会话通过两步验证，之后刷新得到的访问令牌带有 mfa 标记
+func MarkSessionMfaVerified(familyId string) error {
+	return db.Model(&Session{}).
+		Where("family_id = ? AND ended_at IS NULL", familyId).
+		Update("mfa_verified", true).Error
+}
+
+// ListUserSessions 用户仍然有效的会话，超过刷新令牌有效期未活跃的会话视为已失效
+func ListUserSessions(userId uint) ([]Session, error) {
+	var sessions []Session
+	err := db.Where("user_id = ? AND ended_at IS NULL AND last_seen_at > ?", userId, time.Now().Add(-utils.RefreshTokenTTL())).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// EndUserSession 结束用户的某个会话并吊销其令牌族
+func EndUserSession(userId, id uint) error {
+	var s Session
+	if err := db.Where("id = ? AND user_id = ? AND ended_at IS NULL", id, userId).First(&s).Error; err != nil {
+		return err
+	}
+	return RevokeTokenFamily(s.FamilyId)
+}
+
+// MigrateSessions 为已有的令牌族补建会话记录
+func MigrateSessions() {
+	err := db.Exec(`
+		INSERT INTO sessions (created_at, updated_at, user_id, family_id, last_seen_at, ended_at)
+		SELECT MIN(created_at), NOW(), user_id, family_id, MAX(created_at), MAX(revoked_at)
+		FROM refresh_tokens WHERE deleted_at IS NULL
+		GROUP BY user_id, family_id
+		ON CONFLICT DO NOTHING
+	`).Error
+	if err != nil {
+		log.Println("Migrate sessions failed:", err)
+	}
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"devplaza/utils"
+	"log"
+	"time"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// signingKeyLock 轮换时使用的 PostgreSQL 事务级咨询锁，避免多个实例同时生成新密钥
+const signingKeyLock = 720824
+
+// keyPublishDelay 新密钥先发布再启用，保证其他实例和 JWKS 消费方在令牌出现前已拿到公钥
+const keyPublishDelay = 10 * time.Minute
+
+// SigningKey JWT 签名密钥；私钥以 PKCS#8 PEM 保存
+type SigningKey struct {
+	gorm.Model
+	Kid         string     `gorm:"uniqueIndex;not null" json:"kid"`
+	Algorithm   string     `gorm:"not null" json:"algorithm"`
+	PrivateKey  string     `gorm:"type:text;not null" json:"-"`
+	ActivatedAt time.Time  `json:"activated_at"` // 开始签发
+	ExpiresAt   *time.Time `json:"expires_at"`   // 停止验证，为空表示尚未被替换
+}
+
+func signingAlgorithm() string {
+	if alg := viper.GetString("jwt.algorithm"); alg != "" {
+		return alg
+	}
+	return "RS256"
+}
+
+func keyRotationPeriod() time.Duration {
+	if d := viper.GetDuration("jwt.rotationPeriod"); d > 0 {
+		return d
+	}
+	return 30 * 24 * time.Hour
+}
+
+func keyGracePeriod() time.Duration {
+	if d := viper.GetDuration("jwt.gracePeriod"); d > 0 {
+		return d
+	}
+	return 24 * time.Hour
+}
+
+// RotateSigningKeys 没有密钥或当前密钥超过轮换周期时生成新密钥；旧密钥在宽限期后停止验证
+func RotateSigningKeys() error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", signingKeyLock).Error; err != nil {
+			return err
+		}
+
+		var latest SigningKey
+		err := tx.Where("expires_at IS NULL").Order("activated_at DESC").First(&latest).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+
+		now := time.Now()
+		activatedAt := now
+		if err == nil {
+			if latest.ActivatedAt.Add(keyRotationPeriod()).After(now) {
+				return nil
+			}
+			activatedAt = now.Add(keyPublishDelay)
+		}
+
+		key, err := utils.GenerateSigningKey(signingAlgorithm(), activatedAt)
+		if err != nil {
+			return err
+		}
+		privPem, err := utils.MarshalPrivateKey(key.PrivateKey)
+		if err != nil {
+			return err
+		}
+
+		// 旧密钥签发的令牌在新密钥启用后最多还有一个访问令牌有效期
+		expiresAt := activatedAt.Add(utils.AccessTokenTTL()).Add(keyGracePeriod())
+		if err := tx.Model(&SigningKey{}).
+			Where("expires_at IS NULL").
+			Update("expires_at", expiresAt).Error; err != nil {
+			return err
+		}
+
+		log.Printf("signing key %s generated, active from %s", key.Kid, activatedAt.Format(time.RFC3339))
+		return tx.Create(&SigningKey{
+			Kid:         key.Kid,
+			Algorithm:   key.Alg,
+			PrivateKey:  privPem,
+			ActivatedAt: activatedAt,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	return LoadSigningKeys()
+}
+
+// LoadSigningKeys 从数据库加载仍在有效期内的密钥
+func LoadSigningKeys() error {
+	var rows []SigningKey
+	err := db.Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Order("activated_at DESC").
+		Find(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	keys := make([]*utils.SigningKey, 0, len(rows))
+	for _, row := range rows {
+		priv, err := utils.ParsePrivateKey(row.PrivateKey)
+		if err != nil {
+			log.Printf("parse signing key %s failed: %v", row.Kid, err)
+			continue
+		}
+		keys = append(keys, &utils.SigningKey{
+			Kid:         row.Kid,
+			Alg:         row.Algorithm,
+			PrivateKey:  priv,
+			ActivatedAt: row.ActivatedAt,
+		})
+	}
+
+	utils.SetSigningKeys(keys)
+	return nil
+}
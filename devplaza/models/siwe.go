@@ -0,0 +1,35 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SiweNonce Sign-In With Ethereum 一次性随机数
+type SiweNonce struct {
+	gorm.Model
+	Nonce     string     `gorm:"uniqueIndex;not null" json:"nonce"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+func (n *SiweNonce) Create() error {
+	return db.Create(n).Error
+}
+
+// ConsumeSiweNonce 使用随机数，未过期且未使用过才会成功
+func ConsumeSiweNonce(nonce string) error {
+	now := time.Now()
+	res := db.Model(&SiweNonce{}).
+		Where("nonce = ? AND used_at IS NULL AND expires_at > ?", nonce, now).
+		Update("used_at", &now)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("invalid nonce")
+	}
+	return nil
+}
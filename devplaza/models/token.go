@@ -0,0 +1,128 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+)
+
+// RefreshToken 刷新令牌，每次刷新都会轮换，同一次登录产生的令牌属于同一个族（FamilyId）
+type RefreshToken struct {
+	gorm.Model
+	UserId    uint       `gorm:"index;not null" json:"user_id"`
+	FamilyId  string     `gorm:"index;not null" json:"family_id"`
+	TokenHash string     `gorm:"uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`    // 已被轮换
+	RevokedAt *time.Time `json:"revoked_at"` // 已被吊销
+}
+
+func (t *RefreshToken) Create() error {
+	return db.Create(t).Error
+}
+
+// RotateRefreshToken 用旧令牌换取新令牌；旧令牌被重复使用时视为泄露，吊销整个令牌族
+func RotateRefreshToken(tokenHash, newTokenHash string, expiresAt time.Time) (*RefreshToken, error) {
+	var next RefreshToken
+	var reused bool
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var current RefreshToken
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", tokenHash).
+			First(&current).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+
+		if current.RevokedAt != nil || time.Now().After(current.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+
+		if current.UsedAt != nil {
+			reused = true
+			return ErrRefreshTokenReused
+		}
+
+		now := time.Now()
+		if err := tx.Model(&current).Update("used_at", &now).Error; err != nil {
+			return err
+		}
+
+		next = RefreshToken{
+			UserId:    current.UserId,
+			FamilyId:  current.FamilyId,
+			TokenHash: newTokenHash,
+			ExpiresAt: expiresAt,
+		}
+		return tx.Create(&next).Error
+	})
+
+	if reused {
+		// 事务已回滚，单独吊销
+		var current RefreshToken
+		if e := db.Where("token_hash = ?", tokenHash).First(&current).Error; e == nil {
+			_ = RevokeTokenFamily(current.FamilyId)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return &next, nil
+}
+
+// RevokeTokenFamily 吊销令牌族并结束对应会话，该族的刷新令牌和访问令牌立即失效
+func RevokeTokenFamily(familyId string) error {
+	now := time.Now()
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyId).
+			Update("revoked_at", &now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Session{}).
+			Where("family_id = ? AND ended_at IS NULL", familyId).
+			Update("ended_at", &now).Error
+	})
+}
+
+// RevokeUserTokens 吊销用户的全部令牌族并结束全部会话（如重置密码后）
+func RevokeUserTokens(userId uint) error {
+	now := time.Now()
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userId).
+			Update("revoked_at", &now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Session{}).
+			Where("user_id = ? AND ended_at IS NULL", userId).
+			Update("ended_at", &now).Error
+	})
+}
+
+// RevokeOtherUserTokens 吊销用户除 familyId 以外的全部令牌族并结束对应会话（如开启两步验证后）
+func RevokeOtherUserTokens(userId uint, familyId string) error {
+	now := time.Now()
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&RefreshToken{}).
+			Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", userId, familyId).
+			Update("revoked_at", &now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Session{}).
+			Where("user_id = ? AND family_id <> ? AND ended_at IS NULL", userId, familyId).
+			Update("ended_at", &now).Error
+	})
+}
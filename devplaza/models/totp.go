@@ -0,0 +1,145 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrTotpReplayed        = errors.New("totp code already used")
+	ErrInvalidRecoveryCode = errors.New("invalid recovery code")
+	ErrMfaTokenUsed        = errors.New("mfa token already used")
+)
+
+// RecoveryCode 两步验证恢复码，每个只能使用一次
+type RecoveryCode struct {
+	gorm.Model
+	UserId   uint       `gorm:"index;not null" json:"user_id"`
+	CodeHash string     `gorm:"not null" json:"-"`
+	UsedAt   *time.Time `json:"used_at"`
+}
+
+// UsedMfaToken 已经完成登录的两步验证挑战令牌，防止同一令牌被重复使用
+type UsedMfaToken struct {
+	gorm.Model
+	Jti       string    `gorm:"uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time `gorm:"index" json:"expires_at"`
+}
+
+// ConsumeMfaToken 使用挑战令牌，同一令牌只能成功一次
+func ConsumeMfaToken(jti string, expiresAt time.Time) error {
+	res := db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&UsedMfaToken{Jti: jti, ExpiresAt: expiresAt})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrMfaTokenUsed
+	}
+	return nil
+}
+
+// SetPendingTotpSecret 保存待启用的密钥，验证首个验证码后才生效
+func SetPendingTotpSecret(u *User, secret string) error {
+	u.TotpSecret = secret
+	return db.Model(u).UpdateColumn("totp_secret", secret).Error
+}
+
+// EnableTotp 启用两步验证并替换恢复码
+func EnableTotp(u *User, step int64, codeHashes []string) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(u).UpdateColumns(map[string]interface{}{
+			"totp_enabled":   true,
+			"totp_last_step": step,
+		}).Error; err != nil {
+			return err
+		}
+		return replaceRecoveryCodes(tx, u.ID, codeHashes)
+	})
+	if err != nil {
+		return err
+	}
+	u.TotpEnabled = true
+	u.TotpLastStep = step
+	return nil
+}
+
+// DisableTotp 关闭两步验证，删除恢复码，已有会话和访问令牌失去两步验证状态
+func DisableTotp(u *User) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(u).UpdateColumns(map[string]interface{}{
+			"totp_enabled":   false,
+			"totp_secret":    "",
+			"totp_last_step": 0,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&Session{}).Where("user_id = ?", u.ID).Update("mfa_verified", false).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&ApiToken{}).Where("user_id = ?", u.ID).Update("mfa_verified", false).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("user_id = ?", u.ID).Delete(&RecoveryCode{}).Error
+	})
+	if err != nil {
+		return err
+	}
+	u.TotpEnabled = false
+	u.TotpSecret = ""
+	return nil
+}
+
+// ReplaceRecoveryCodes 重新生成恢复码，旧的全部作废
+func ReplaceRecoveryCodes(userId uint, codeHashes []string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userId, codeHashes)
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userId uint, codeHashes []string) error {
+	if err := tx.Unscoped().Where("user_id = ?", userId).Delete(&RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	codes := make([]RecoveryCode, 0, len(codeHashes))
+	for _, h := range codeHashes {
+		codes = append(codes, RecoveryCode{UserId: userId, CodeHash: h})
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	return tx.Create(&codes).Error
+}
+
+// UseTotpStep 记录已使用的时间步，同一时间步或更早的验证码不能再次使用
+func UseTotpStep(u *User, step int64) error {
+	res := db.Model(&User{}).
+		Where("id = ? AND totp_last_step < ?", u.ID, step).
+		UpdateColumn("totp_last_step", step)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrTotpReplayed
+	}
+	u.TotpLastStep = step
+	return nil
+}
+
+// ConsumeRecoveryCode 使用一个恢复码
+func ConsumeRecoveryCode(userId uint, codeHash string) error {
+	now := time.Now()
+	res := db.Model(&RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userId, codeHash).
+		Update("used_at", &now)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrInvalidRecoveryCode
+	}
+	return nil
+}
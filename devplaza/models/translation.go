@@ -0,0 +1,176 @@
+package models
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidTranslation = errors.New("invalid translation source")
+	ErrTranslationExists  = errors.New("translation for this language already exists")
+	ErrTranslationInGroup = errors.New("content is already a translation of another source, detach it first")
+)
+
+// Translation 翻译组中的一篇内容
+type Translation struct {
+	Id            uint   `json:"id"`
+	Title         string `json:"title"`
+	Language      string `json:"language"`
+	PublishStatus uint   `json:"publish_status"`
+	Original      bool   `json:"original"` // 是否为原文
+	Outdated      bool   `json:"outdated"` // 原文在翻译后又有修改
+}
+
+// translationTables 支持翻译的内容类型
+var translationTables = map[string]string{ContentBlog: "articles", ContentTutorial: "tutorials"}
+
+// ListTranslations 翻译组中的原文和所有译文，原文在前；publishedOnly 为 true 时只返回已发布的
+func ListTranslations(contentType string, groupId uint, publishedOnly bool) ([]Translation, error) {
+	translations := []Translation{}
+	table, ok := translationTables[contentType]
+	if !ok {
+		return translations, nil
+	}
+	sql := `SELECT c.id, c.title, c.language, c.publish_status, c.id = c.translation_group_id AS original,
+			c.id <> c.translation_group_id AND c.translation_source_version <
+				(SELECT COALESCE(MAX(r.version), 0) FROM revisions r
+				WHERE r.content_type = ? AND r.content_id = c.translation_group_id) AS outdated
+		FROM ` + table + ` c
+		WHERE c.translation_group_id = ? AND c.deleted_at IS NULL`
+	if publishedOnly {
+		sql += ` AND c.publish_status = 2`
+	}
+	err := db.Raw(sql+` ORDER BY original DESC, c.id ASC`, contentType, groupId).Scan(&translations).Error
+	return translations, err
+}
+
+// TranslationOutdated 译文的原文在 sourceVersion 之后是否又有修改，原文和不属于翻译组的内容返回 false
+func TranslationOutdated(contentType string, id uint, groupId *uint, sourceVersion int) bool {
+	if groupId == nil || *groupId == id {
+		return false
+	}
+	latest, err := GetRevision(contentType, *groupId, 0)
+	if err != nil {
+		return false
+	}
+	return latest.Version > sourceVersion
+}
+
+// TranslationLanguageExists 翻译组中除 excludeId 外是否已有该语言的内容
+func TranslationLanguageExists(contentType string, groupId uint, language string, excludeId uint) (bool, error) {
+	return translationLanguageExists(db, contentType, groupId, language, excludeId)
+}
+
+func translationLanguageExists(tx *gorm.DB, contentType string, groupId uint, language string, excludeId uint) (bool, error) {
+	var count int64
+	err := tx.Table(translationTables[contentType]).
+		Where("translation_group_id = ? AND language = ? AND id <> ? AND deleted_at IS NULL", groupId, language, excludeId).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// AttachTranslation 将内容作为 sourceId 的译文加入翻译组，并记录原文当前的修订版本。
+// 已是该原文的译文时只更新同步的版本，返回同步的原文版本号
+func AttachTranslation(contentType string, id, sourceId uint) (int, error) {
+	table, ok := translationTables[contentType]
+	if !ok || id == sourceId {
+		return 0, ErrInvalidTranslation
+	}
+
+	var version int
+	err := db.Transaction(func(tx *gorm.DB) error {
+		type row struct {
+			Id                 uint
+			Language           string
+			TranslationGroupId *uint
+		}
+		var source, translation row
+		// 锁住原文，同一原文的译文按顺序加入
+		if err := tx.Raw(`SELECT id, language, translation_group_id FROM `+table+`
+			WHERE id = ? AND deleted_at IS NULL FOR UPDATE`, sourceId).Scan(&source).Error; err != nil {
+			return err
+		}
+		if source.Id == 0 {
+			return ErrInvalidTranslation
+		}
+		// 只能翻译原文，不能翻译译文
+		if source.TranslationGroupId != nil && *source.TranslationGroupId != source.Id {
+			return ErrInvalidTranslation
+		}
+		if err := tx.Raw(`SELECT id, language, translation_group_id FROM `+table+`
+			WHERE id = ? AND deleted_at IS NULL`, id).Scan(&translation).Error; err != nil {
+			return err
+		}
+		if translation.Id == 0 || translation.Language == source.Language {
+			return ErrInvalidTranslation
+		}
+		// 已是其他原文的译文时需要先移出原来的翻译组，否则原来的原文会留在没有译文的翻译组中
+		if translation.TranslationGroupId != nil && *translation.TranslationGroupId != translation.Id &&
+			*translation.TranslationGroupId != sourceId {
+			return ErrTranslationInGroup
+		}
+		// 已有译文的原文不能再作为其他内容的译文
+		if translation.TranslationGroupId != nil && *translation.TranslationGroupId == translation.Id {
+			var others int64
+			if err := tx.Table(table).
+				Where("translation_group_id = ? AND id <> ? AND deleted_at IS NULL", id, id).
+				Count(&others).Error; err != nil {
+				return err
+			}
+			if others > 0 {
+				return ErrInvalidTranslation
+			}
+		}
+		exists, err := translationLanguageExists(tx, contentType, sourceId, translation.Language, id)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrTranslationExists
+		}
+
+		if err := tx.Model(&Revision{}).
+			Where("content_type = ? AND content_id = ?", contentType, sourceId).
+			Select("COALESCE(MAX(version), 0)").Scan(&version).Error; err != nil {
+			return err
+		}
+		if err := tx.Table(table).Where("id = ? AND translation_group_id IS NULL", sourceId).
+			UpdateColumn("translation_group_id", sourceId).Error; err != nil {
+			return err
+		}
+		return tx.Table(table).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+			"translation_group_id":       sourceId,
+			"translation_source_version": version,
+		}).Error
+	})
+	return version, err
+}
+
+// DetachTranslation 将内容移出翻译组。移出原文时解散整个翻译组；移出最后一篇译文时原文也不再属于翻译组
+func DetachTranslation(contentType string, id uint, groupId uint) error {
+	table, ok := translationTables[contentType]
+	if !ok {
+		return ErrInvalidTranslation
+	}
+	reset := map[string]interface{}{"translation_group_id": nil, "translation_source_version": 0}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if groupId == id {
+			return tx.Table(table).Where("translation_group_id = ?", groupId).UpdateColumns(reset).Error
+		}
+		if err := tx.Table(table).Where("id = ? AND translation_group_id = ?", id, groupId).
+			UpdateColumns(reset).Error; err != nil {
+			return err
+		}
+		var others int64
+		if err := tx.Table(table).
+			Where("translation_group_id = ? AND id <> ? AND deleted_at IS NULL", groupId, groupId).
+			Count(&others).Error; err != nil {
+			return err
+		}
+		if others > 0 {
+			return nil
+		}
+		return tx.Table(table).Where("id = ?", groupId).UpdateColumns(reset).Error
+	})
+}
@@ -10,28 +10,48 @@ import (
 
 type Tutorial struct {
 	gorm.Model
-	Title         string         `json:"title"`
-	Description   string         `json:"description"`
-	Content       string         `gorm:"type:text" json:"content"`
-	SourceLink    string         `json:"source_link"`
-	CoverImg      string         `json:"cover_img"`
-	Tags          pq.StringArray `gorm:"type:text[]" json:"tags"`
-	Author        string         `json:"author"`
-	PublisherId   uint           `json:"publisher_id"`
-	Publisher     *User          `gorm:"foreignKey:PublisherId" json:"publisher"`
-	PublishTime   *time.Time     `json:"publish_time"`
-	PublishStatus uint           `gorm:"default:1" json:"publish_status"` // 0:全部 1:待审核 2:已发布
-	DappId        *uint          `json:"dapp_id"`
-	Dapp          *Dapp          `gorm:"foreignKey:DappId" json:"dapp"`
-	ViewCount     uint           `gorm:"default:0" json:"view_count"`
+	Title          string         `json:"title"`
+	Description    string         `json:"description"`
+	Content        string         `gorm:"type:text" json:"content"`
+	SourceLink     string         `json:"source_link"`
+	CoverImg       string         `json:"cover_img"`
+	Tags           pq.StringArray `gorm:"type:text[]" json:"tags"`
+	Author         string         `json:"author"`
+	PublisherId    uint           `json:"publisher_id"`
+	Publisher      *User          `gorm:"foreignKey:PublisherId" json:"publisher"`
+	PublishTime    *time.Time     `json:"publish_time"`
+	PublishAt      *time.Time     `json:"publish_at"`                      // 定时发布时间
+	PublishStatus  uint           `gorm:"default:1" json:"publish_status"` // 见 workflow.Status，1:待审核 2:已发布 3:草稿 4:需修改 5:已下架 6:已归档 7:定时发布
+	DappId         *uint          `json:"dapp_id"`
+	Dapp           *Dapp          `gorm:"foreignKey:DappId" json:"dapp"`
+	ViewCount      uint           `gorm:"default:0" json:"view_count"`
+	SeriesId       *uint          `gorm:"index" json:"series_id"`
+	SeriesPosition int            `gorm:"default:0" json:"series_position"` // 在系列中的顺序，从 1 开始
+	SeriesPrev     *SeriesChapter `gorm:"-" json:"series_prev,omitempty"`   // 系列中已发布的上一章
+	SeriesNext     *SeriesChapter `gorm:"-" json:"series_next,omitempty"`   // 系列中已发布的下一章
+
+	RenderedContent // 正文处理后的 HTML、目录和统计信息
+
+	// 多语言，原文和译文属于同一个翻译组
+	Language                 string `gorm:"default:zh;index" json:"language"`  // zh 或 en
+	TranslationGroupId       *uint  `gorm:"index" json:"translation_group_id"` // 原文的 ID，原文被翻译后也会设置为自身 ID
+	TranslationSourceVersion int    `json:"translation_source_version"`        // 译文最近一次同步时原文的修订版本
+	TranslationOutdated      bool   `gorm:"-" json:"translation_outdated"`     // 原文在翻译后又有修改
+
+	Contributors []Contributor `gorm:"polymorphicType:ContentType;polymorphicId:ContentId;polymorphicValue:tutorial" json:"contributors"`
 }
 
 func (t *Tutorial) Create() error {
 	return db.Create(t).Error
 }
 
+// Load 读取教程，不增加浏览量，用于编辑、审核等管理操作
+func (t *Tutorial) Load() error {
+	return db.Preload("Publisher").Preload("Dapp").Preload("Contributors", "accepted_at IS NOT NULL").Preload("Contributors.User").First(t, t.ID).Error
+}
+
 func (t *Tutorial) GetByID() error {
-	if err := db.Preload("Publisher").Preload("Dapp").First(t, t.ID).Error; err != nil {
+	if err := t.Load(); err != nil {
 		return err
 	}
 
@@ -59,10 +79,12 @@ type TutorialFilter struct {
 	Tag           string // 包含某个 tag
 	DappId        uint
 	PublisherId   int
-	OrderDesc     bool // 是否按发布时间排序
-	PublishStatus int  // 发布状态
-	Page          int  // 当前页码，从 1 开始
-	PageSize      int  // 每页数量，建议默认 10
+	OrderDesc     bool   // 是否按发布时间排序
+	PublishStatus int    // 发布状态
+	Language      string // 语言，如 zh、en
+	SeriesId      uint   // 系列，指定时按章节顺序排列
+	Page          int    // 当前页码，从 1 开始
+	PageSize      int    // 每页数量，建议默认 10
 }
 
 func QueryTutorials(filter TutorialFilter) ([]Tutorial, int64, error) {
@@ -92,11 +114,21 @@ func QueryTutorials(filter TutorialFilter) ([]Tutorial, int64, error) {
 		query = query.Where("publish_status = ?", filter.PublishStatus)
 	}
 
+	if filter.Language != "" {
+		query = query.Where("language = ?", filter.Language)
+	}
+
+	if filter.SeriesId != 0 {
+		query = query.Where("series_id = ?", filter.SeriesId)
+	}
+
 	// 统计总数（不加 limit 和 offset）
 	query.Count(&total)
 
 	// 排序
-	if filter.OrderDesc {
+	if filter.SeriesId != 0 {
+		query = query.Order("series_position asc")
+	} else if filter.OrderDesc {
 		query = query.Order("publish_time desc")
 	} else {
 		query = query.Order("publish_time asc")
@@ -1,7 +1,11 @@
 package models
 
 import (
+	"sort"
+	"time"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type User struct {
@@ -11,12 +15,24 @@ type User struct {
 	Avatar   string    `json:"avatar"`
 	Github   string    `json:"github"`
 	Twitter  string    `json:"twitter"`
-	Uid      uint      `json:"-"` // OAUTH
-	RoleID   uint      `json:"-"`
-	Role     *Role     `gorm:"foreignKey:RoleID" json:"-"`
+	Uid      uint      `json:"-"`                    // OAUTH，登录已改为通过 UserIdentity 关联
+	Address  string    `gorm:"index" json:"address"` // 钱包地址（SIWE 登录）
+	RoleID   uint      `json:"-"`                    // 旧的单一角色，已复制到 Roles，不再使用
+	Roles    []Role    `gorm:"many2many:user_roles;" json:"-"`
 	Events   []Event   `gorm:"foreignKey:UserId" json:"events"`
 	Articles []Article `gorm:"foreignKey:PublisherId"  json:"articles"`
 	Posts    []Post    `gorm:"foreignKey:UserId" json:"posts"`
+
+	// 本地账号（邮箱 + 密码）
+	PasswordHash    string     `json:"-"`
+	EmailVerifiedAt *time.Time `json:"-"`
+	FailedLogins    int        `gorm:"default:0" json:"-"`
+	LockedUntil     *time.Time `json:"-"`
+
+	// 两步验证（TOTP）
+	TotpSecret   string `json:"-"`
+	TotpEnabled  bool   `gorm:"default:false" json:"totp_enabled"`
+	TotpLastStep int64  `gorm:"default:0" json:"-"` // 最近一次使用的时间步，防止验证码重放
 }
 
 func GetUserByUid(uid uint) (*User, error) {
@@ -41,8 +57,8 @@ func CreateUser(u *User) error {
 		return err
 	}
 
-	// 设置默认角色 ID
-	u.RoleID = role.ID
+	// 设置默认角色
+	u.Roles = []Role{role}
 
 	if err := db.Create(u).Error; err != nil {
 		return err
@@ -54,6 +70,8 @@ func UpdateUser(u *User) error {
 	if err := db.Save(u).Error; err != nil {
 		return err
 	}
+	// 可能修改了角色
+	InvalidateUserPermissions(u.ID)
 	return nil
 }
 
@@ -64,34 +82,76 @@ func GetUserByEmail(u *User) error {
 	return nil
 }
 
+// IsLocked 连续登录失败后账号被临时锁定
+func (u *User) IsLocked() bool {
+	return u.LockedUntil != nil && time.Now().Before(*u.LockedUntil)
+}
+
+// RecordLoginFailure 记录一次密码错误，达到上限后锁定账号并重新计数
+// 计数在同一条 SQL 中完成，并发的失败请求不会互相覆盖
+func RecordLoginFailure(u *User, maxAttempts int, lockFor time.Duration) error {
+	lockedUntil := time.Now().Add(lockFor)
+	err := db.Model(u).Clauses(clause.Returning{Columns: []clause.Column{{Name: "failed_logins"}, {Name: "locked_until"}}}).
+		UpdateColumns(map[string]interface{}{
+			"failed_logins": gorm.Expr("CASE WHEN failed_logins + 1 >= ? THEN 0 ELSE failed_logins + 1 END", maxAttempts),
+			"locked_until":  gorm.Expr("CASE WHEN failed_logins + 1 >= ? THEN ? ELSE locked_until END", maxAttempts, lockedUntil),
+		}).Error
+	return err
+}
+
+// ResetLoginFailures 登录成功或重置密码后清除失败记录
+func ResetLoginFailures(u *User) error {
+	u.FailedLogins = 0
+	u.LockedUntil = nil
+	return db.Model(u).UpdateColumns(map[string]interface{}{
+		"failed_logins": 0,
+		"locked_until":  nil,
+	}).Error
+}
+
+// GetUserWithPermissions 获取用户权限，优先读缓存
 func GetUserWithPermissions(uid uint) ([]string, error) {
+	if perms, ok := permissionCache.Get(uid); ok {
+		return append([]string(nil), perms...), nil
+	}
+
+	perms, err := loadUserPermissions(uid)
+	if err != nil {
+		return nil, err
+	}
+	permissionCache.Set(uid, perms)
+	return append([]string(nil), perms...), nil
+}
+
+func loadUserPermissions(uid uint) ([]string, error) {
 	var user User
-	err := db.Preload("Role").
-		Preload("Role.Permissions").
-		Preload("Role.PermissionGroups.Permissions").
+	err := db.Preload("Roles.Permissions").
+		Preload("Roles.PermissionGroups.Permissions").
 		First(&user, uid).Error
 	if err != nil {
 		return nil, err
 	}
 
 	permSet := map[string]struct{}{}
-
-	// 角色直接权限
-	for _, p := range user.Role.Permissions {
-		permSet[p.Name] = struct{}{}
-	}
-
-	// 权限组权限
-	for _, pg := range user.Role.PermissionGroups {
-		for _, p := range pg.Permissions {
+	for _, role := range user.Roles {
+		// 角色直接权限
+		for _, p := range role.Permissions {
 			permSet[p.Name] = struct{}{}
 		}
+
+		// 权限组权限
+		for _, pg := range role.PermissionGroups {
+			for _, p := range pg.Permissions {
+				permSet[p.Name] = struct{}{}
+			}
+		}
 	}
 
 	perms := make([]string, 0, len(permSet))
 	for name := range permSet {
 		perms = append(perms, name)
 	}
+	sort.Strings(perms)
 	return perms, nil
 }
 
@@ -0,0 +1,173 @@
+package policy
+
+// Action 对资源的操作
+type Action string
+
+const (
+	Update Action = "update"
+	Delete Action = "delete"
+	Review Action = "review"
+)
+
+// 资源类型
+const (
+	Blog     = "blog"
+	Tutorial = "tutorial"
+	Event    = "event"
+	Dapp     = "dapp"
+	Post     = "post"
+	Recap    = "recap"
+	Series   = "series"
+	User     = "user"
+)
+
+// 审核范围类型
+const (
+	ScopeCategory = "category"
+	ScopeDapp     = "dapp"
+)
+
+// Scope 审核范围，如某个分类或某个 Dapp
+type Scope struct {
+	Type string
+	Id   uint
+}
+
+// Grant 限定在某个范围内的权限，如只能审核 DeFi 分类下的内容
+type Grant struct {
+	Permission string
+	Scope      Scope
+}
+
+// Subject 发起操作的用户
+type Subject struct {
+	UserId      uint
+	Permissions []string // 已生效的权限（未通过两步验证时不含审核、删除权限）
+	Grants      []Grant  // 限定范围的审核权限
+	Mfa         bool     // 是否通过两步验证，未通过时限定范围的审核权限不生效
+}
+
+func (s Subject) has(permission string) bool {
+	for _, p := range s.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// moderates 是否为资源的审核员：拥有全局审核权限，或在资源所属范围内被指定为审核员
+func (s Subject) moderates(permission string, r Resource) bool {
+	if s.has(permission) {
+		return true
+	}
+	if !s.Mfa {
+		return false
+	}
+	for _, g := range s.Grants {
+		if g.Permission != permission {
+			continue
+		}
+		for _, scope := range r.Scopes {
+			if g.Scope == scope {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Resource 被操作的资源
+type Resource struct {
+	Type         string
+	OwnerId      uint
+	Contributors []uint  // 共同作者、译者、编辑，与作者一样可以修改，但不能删除
+	Scopes       []Scope // 资源所属的分类、Dapp 等
+}
+
+// IsOwner 用户是否为资源的作者本人（发布者），下架、归档等状态变更只有作者本人可以执行
+func (r Resource) IsOwner(userId uint) bool {
+	return userId != 0 && userId == r.OwnerId
+}
+
+// IsAuthor 用户是否为资源的作者或贡献者
+func (r Resource) IsAuthor(userId uint) bool {
+	if userId == 0 {
+		return false
+	}
+	if r.IsOwner(userId) {
+		return true
+	}
+	for _, id := range r.Contributors {
+		if id == userId {
+			return true
+		}
+	}
+	return false
+}
+
+// permissionPrefix 资源类型对应的权限前缀；动态和活动回顾沿用博客权限，系列沿用教程权限
+var permissionPrefix = map[string]string{
+	Blog:     "blog",
+	Tutorial: "tutorial",
+	Event:    "event",
+	Dapp:     "dapp",
+	Post:     "blog",
+	Recap:    "blog",
+	Series:   "tutorial",
+}
+
+// Can 判断用户能否对资源执行操作：
+//   - update：作者本人或贡献者且有 <p>:write，或全局审核员（<p>:review）
+//   - delete：作者本人且有 <p>:delete，或全局审核员
+//   - review：全局审核员，或资源范围内的审核员
+//
+// 限定范围的审核员只能审核（含发布、驳回等状态流转），不能修改或删除内容。
+// 用户资料只能由本人修改。
+func Can(s Subject, action Action, r Resource) bool {
+	if s.UserId == 0 {
+		return false
+	}
+
+	if r.Type == User {
+		return action == Update && s.UserId == r.OwnerId
+	}
+
+	prefix, ok := permissionPrefix[r.Type]
+	if !ok {
+		return false
+	}
+
+	globalModerator := s.has(prefix + ":review")
+
+	switch action {
+	case Update:
+		return globalModerator || (r.IsAuthor(s.UserId) && s.has(prefix+":write"))
+	case Delete:
+		return globalModerator || (r.IsOwner(s.UserId) && s.has(prefix+":delete"))
+	case Review:
+		return s.moderates(prefix+":review", r)
+	}
+	return false
+}
+
+// ReviewScopes 用户可审核的某类资源：all 为 true 时可审核全部，否则只能审核 scopes 范围内的资源
+func ReviewScopes(s Subject, resourceType string) (all bool, scopes []Scope) {
+	prefix, ok := permissionPrefix[resourceType]
+	if !ok || s.UserId == 0 {
+		return false, nil
+	}
+	permission := prefix + ":review"
+	if s.has(permission) {
+		return true, nil
+	}
+	if !s.Mfa {
+		return false, nil
+	}
+	for _, g := range s.Grants {
+		if g.Permission == permission {
+			scopes = append(scopes, g.Scope)
+		}
+	}
+	return false, scopes
+}
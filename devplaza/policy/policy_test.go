@@ -0,0 +1,195 @@
+package policy
+
+import "testing"
+
+var (
+	writer    = []string{"blog:write", "blog:delete", "tutorial:write", "tutorial:delete"}
+	moderator = []string{"blog:write", "blog:review", "blog:delete", "blog:publish"}
+)
+
+func sub(id uint, perms []string) Subject {
+	return Subject{UserId: id, Permissions: perms}
+}
+
+func res(typ string, owner uint) Resource {
+	return Resource{Type: typ, OwnerId: owner}
+}
+
+func TestCan(t *testing.T) {
+	cases := []struct {
+		name     string
+		subject  Subject
+		action   Action
+		resource Resource
+		want     bool
+	}{
+		// 博客
+		{"blog owner update", sub(1, writer), Update, res(Blog, 1), true},
+		{"blog owner delete", sub(1, writer), Delete, res(Blog, 1), true},
+		{"blog other update", sub(2, writer), Update, res(Blog, 1), false},
+		{"blog other delete", sub(2, writer), Delete, res(Blog, 1), false},
+		{"blog owner review", sub(1, writer), Review, res(Blog, 1), false},
+		{"blog moderator update", sub(3, moderator), Update, res(Blog, 1), true},
+		{"blog moderator delete", sub(3, moderator), Delete, res(Blog, 1), true},
+		{"blog moderator review", sub(3, moderator), Review, res(Blog, 1), true},
+		{"blog owner without write", sub(1, nil), Update, res(Blog, 1), false},
+
+		// 教程
+		{"tutorial owner update", sub(1, writer), Update, res(Tutorial, 1), true},
+		{"tutorial other delete", sub(2, writer), Delete, res(Tutorial, 1), false},
+		{"tutorial blog moderator", sub(3, moderator), Update, res(Tutorial, 1), false},
+		{"tutorial moderator", sub(3, []string{"tutorial:review"}), Delete, res(Tutorial, 1), true},
+
+		// 活动
+		{"event owner update", sub(1, []string{"event:write"}), Update, res(Event, 1), true},
+		{"event other update", sub(2, []string{"event:write"}), Update, res(Event, 1), false},
+		{"event owner delete without permission", sub(1, []string{"event:write"}), Delete, res(Event, 1), false},
+		{"event admin delete", sub(3, []string{"event:write", "event:review", "event:delete"}), Delete, res(Event, 1), true},
+
+		// Dapp
+		{"dapp delete permission only", sub(2, []string{"dapp:delete"}), Delete, res(Dapp, 1), false},
+		{"dapp owner delete", sub(1, []string{"dapp:write", "dapp:delete"}), Delete, res(Dapp, 1), true},
+		{"dapp admin delete", sub(3, []string{"dapp:review"}), Delete, res(Dapp, 1), true},
+		{"dapp without owner", sub(2, []string{"dapp:write", "dapp:delete"}), Delete, res(Dapp, 0), false},
+
+		// 动态、活动回顾沿用博客权限
+		{"post owner update", sub(1, writer), Update, res(Post, 1), true},
+		{"post other delete", sub(2, writer), Delete, res(Post, 1), false},
+		{"post moderator delete", sub(3, moderator), Delete, res(Post, 1), true},
+		{"recap owner delete", sub(1, writer), Delete, res(Recap, 1), true},
+		{"recap other update", sub(2, writer), Update, res(Recap, 1), false},
+
+		// 系列沿用教程权限
+		{"series owner update", sub(1, writer), Update, res(Series, 1), true},
+		{"series other update", sub(2, writer), Update, res(Series, 1), false},
+		{"series tutorial moderator", sub(3, []string{"tutorial:review"}), Delete, res(Series, 1), true},
+		{"series blog moderator", sub(3, moderator), Update, res(Series, 1), false},
+
+		// 用户资料
+		{"user self update", sub(1, nil), Update, res(User, 1), true},
+		{"user other update", sub(2, moderator), Update, res(User, 1), false},
+		{"user self delete", sub(1, nil), Delete, res(User, 1), false},
+
+		// 其他
+		{"anonymous", sub(0, moderator), Review, res(Blog, 1), false},
+		{"unknown type", sub(1, writer), Update, res("unknown", 1), false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Can(tc.subject, tc.action, tc.resource); got != tc.want {
+				t.Errorf("Can(%+v, %s, %+v) = %v, want %v", tc.subject, tc.action, tc.resource, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCanScoped(t *testing.T) {
+	defi := Scope{ScopeCategory, 10}
+	uniswap := Scope{ScopeDapp, 5}
+
+	tutorialModerator := Subject{
+		UserId: 3,
+		Grants: []Grant{{"tutorial:review", uniswap}},
+		Mfa:    true,
+	}
+	defiModerator := Subject{
+		UserId: 4,
+		Grants: []Grant{{"dapp:review", defi}, {"tutorial:review", defi}},
+		Mfa:    true,
+	}
+
+	uniswapTutorial := Resource{Type: Tutorial, OwnerId: 1, Scopes: []Scope{uniswap, defi}}
+	otherTutorial := Resource{Type: Tutorial, OwnerId: 1, Scopes: []Scope{{ScopeDapp, 6}, {ScopeCategory, 11}}}
+	defiDapp := Resource{Type: Dapp, OwnerId: 1, Scopes: []Scope{defi, {ScopeDapp, 7}}}
+
+	withoutMfa := tutorialModerator
+	withoutMfa.Mfa = false
+
+	cases := []struct {
+		name     string
+		subject  Subject
+		action   Action
+		resource Resource
+		want     bool
+	}{
+		{"dapp scope review", tutorialModerator, Review, uniswapTutorial, true},
+		{"dapp scope update", tutorialModerator, Update, uniswapTutorial, false},
+		{"dapp scope delete", tutorialModerator, Delete, uniswapTutorial, false},
+		{"category scope dapp update", defiModerator, Update, defiDapp, false},
+		{"dapp scope other dapp", tutorialModerator, Review, otherTutorial, false},
+		{"dapp scope wrong type", tutorialModerator, Review, defiDapp, false},
+		{"category scope tutorial", defiModerator, Review, uniswapTutorial, true},
+		{"category scope dapp", defiModerator, Review, defiDapp, true},
+		{"category scope other category", defiModerator, Review, otherTutorial, false},
+		{"scope without mfa", withoutMfa, Review, uniswapTutorial, false},
+		{"scope unscoped resource", tutorialModerator, Review, Resource{Type: Tutorial, OwnerId: 1}, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Can(tc.subject, tc.action, tc.resource); got != tc.want {
+				t.Errorf("Can() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCanContributor(t *testing.T) {
+	article := Resource{Type: Blog, OwnerId: 1, Contributors: []uint{2}}
+
+	cases := []struct {
+		name    string
+		subject Subject
+		action  Action
+		want    bool
+	}{
+		{"contributor update", sub(2, writer), Update, true},
+		{"contributor without write", sub(2, nil), Update, false},
+		{"contributor delete", sub(2, writer), Delete, false},
+		{"contributor review", sub(2, writer), Review, false},
+		{"other update", sub(3, writer), Update, false},
+		{"owner update", sub(1, writer), Update, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Can(tc.subject, tc.action, article); got != tc.want {
+				t.Errorf("Can() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	if (Resource{Contributors: []uint{0}}).IsAuthor(0) {
+		t.Error("anonymous user is not an author")
+	}
+	if !article.IsOwner(1) || article.IsOwner(2) || (Resource{}).IsOwner(0) {
+		t.Error("only the publisher is the owner")
+	}
+}
+
+func TestReviewScopes(t *testing.T) {
+	defi := Scope{ScopeCategory, 10}
+	scoped := Subject{UserId: 4, Grants: []Grant{{"tutorial:review", defi}, {"dapp:review", defi}}, Mfa: true}
+
+	if all, scopes := ReviewScopes(sub(3, moderator), Blog); !all || scopes != nil {
+		t.Fatalf("global moderator: all=%v scopes=%v", all, scopes)
+	}
+	if all, scopes := ReviewScopes(sub(3, moderator), Post); !all || scopes != nil {
+		t.Fatalf("post uses blog review: all=%v scopes=%v", all, scopes)
+	}
+	if all, scopes := ReviewScopes(sub(1, writer), Blog); all || scopes != nil {
+		t.Fatalf("writer: all=%v scopes=%v", all, scopes)
+	}
+	if all, scopes := ReviewScopes(scoped, Tutorial); all || len(scopes) != 1 || scopes[0] != defi {
+		t.Fatalf("scoped tutorial moderator: all=%v scopes=%v", all, scopes)
+	}
+	if all, scopes := ReviewScopes(scoped, Event); all || scopes != nil {
+		t.Fatalf("scoped moderator on events: all=%v scopes=%v", all, scopes)
+	}
+
+	scoped.Mfa = false
+	if all, scopes := ReviewScopes(scoped, Tutorial); all || scopes != nil {
+		t.Fatalf("scopes without mfa: all=%v scopes=%v", all, scopes)
+	}
+}
@@ -10,7 +10,19 @@ import (
 func SetupRouter(r *gin.Engine) {
 	r.Use(middlewares.Cors())
 
+	r.GET("/.well-known/jwks.json", controllers.JWKS)
+
 	r.POST("/v1/login", controllers.HandleLogin)
+	r.GET("/v1/login/siwe/nonce", controllers.GetSiweNonce)
+	r.POST("/v1/login/siwe", controllers.HandleSiweLogin)
+	r.POST("/v1/login/password", controllers.HandlePasswordLogin)
+	r.POST("/v1/login/2fa", controllers.HandleMfaLogin)
+	r.POST("/v1/register", controllers.Register)
+	r.POST("/v1/register/verify", controllers.VerifyEmail)
+	r.POST("/v1/password/forgot", controllers.ForgotPassword)
+	r.POST("/v1/password/reset", controllers.ResetPassword)
+	r.POST("/v1/logout", middlewares.JWT(""), controllers.Logout)
+	r.POST("/v1/token/refresh", controllers.RefreshToken)
 
 	user := r.Group("v1/users")
 	{
@@ -19,6 +31,27 @@ func SetupRouter(r *gin.Engine) {
 		user.POST("/follow/:id", middlewares.JWT(""), controllers.FollowUser)
 		user.POST("/unfollow/:id", middlewares.JWT(""), controllers.UnfollowUser)
 		user.POST("/follow/states", middlewares.JWT(""), controllers.GetFollowStates)
+
+		user.GET("/me/identities", middlewares.JWT(""), controllers.ListMyIdentities)
+		user.POST("/me/identities", middlewares.JWT(""), controllers.LinkIdentity)
+		user.POST("/me/identities/siwe", middlewares.JWT(""), controllers.LinkSiweIdentity)
+		user.DELETE("/me/identities/:id", middlewares.JWT(""), controllers.UnlinkIdentity)
+		// 博客、教程的贡献者邀请
+		user.GET("/me/invitations", middlewares.JWT(""), controllers.ListMyInvitations)
+		user.POST("/me/invitations/:id/accept", middlewares.JWT(""), controllers.AcceptInvitation)
+		user.DELETE("/me/invitations/:id", middlewares.JWT(""), controllers.DeclineInvitation)
+
+		user.GET("/me/tokens", middlewares.JWT(""), controllers.ListApiTokens)
+		user.POST("/me/tokens", middlewares.JWT(""), controllers.CreateApiToken)
+		user.DELETE("/me/tokens/:id", middlewares.JWT(""), controllers.RevokeApiToken)
+
+		user.GET("/me/sessions", middlewares.JWT(""), controllers.ListMySessions)
+		user.DELETE("/me/sessions/:id", middlewares.JWT(""), controllers.EndMySession)
+
+		user.POST("/me/2fa/setup", middlewares.JWT(""), controllers.SetupTotp)
+		user.POST("/me/2fa/enable", middlewares.JWT(""), controllers.EnableTotp)
+		user.POST("/me/2fa/disable", middlewares.JWT(""), controllers.DisableTotp)
+		user.POST("/me/2fa/recovery-codes", middlewares.JWT(""), controllers.RegenerateRecoveryCodes)
 	}
 
 	event := r.Group("/v1/events")
@@ -28,7 +61,11 @@ func SetupRouter(r *gin.Engine) {
 		event.PUT("/:id", middlewares.JWT("event:write"), controllers.UpdateEvent)
 		event.GET("", controllers.QueryEvents)
 		event.GET("/:id", controllers.GetEvent)
-		event.PUT("/:id/status", middlewares.JWT("event:review"), controllers.UpdateEventPublishStatus)
+		// 作者和审核员都可以变更状态，可执行的变更在控制器中按状态机和策略检查
+		event.PUT("/:id/status", middlewares.JWT("", middlewares.AllowApiToken), controllers.UpdateEventPublishStatus)
+		event.GET("/:id/transitions", middlewares.JWT("", middlewares.AllowApiToken), controllers.GetEventTransitions)
+		event.PUT("/:id/schedule", middlewares.JWT(""), controllers.ScheduleEvent)
+		event.DELETE("/:id/schedule", middlewares.JWT(""), controllers.CancelEventSchedule)
 
 		// 发布博客是用户默认权限， 这里任何用户都可以添加recap
 		event.POST("/recap", middlewares.JWT("blog:write"), controllers.CreateReacp)
@@ -43,15 +80,30 @@ func SetupRouter(r *gin.Engine) {
 		blog.PUT("/:id", middlewares.JWT("blog:write"), controllers.UpdateArticle)
 		blog.GET("/:id", controllers.GetArticle)
 		blog.GET("", controllers.QueryArticles)
-		blog.PUT("/:id/status", middlewares.JWT("blog:review"), controllers.UpdateArticlePublishStatus)
+		blog.PUT("/:id/status", middlewares.JWT("", middlewares.AllowApiToken), controllers.UpdateArticlePublishStatus)
+		blog.GET("/:id/transitions", middlewares.JWT("", middlewares.AllowApiToken), controllers.GetArticleTransitions)
+		blog.PUT("/:id/schedule", middlewares.JWT(""), controllers.ScheduleArticle)
+		blog.DELETE("/:id/schedule", middlewares.JWT(""), controllers.CancelArticleSchedule)
+		blog.GET("/:id/revisions", middlewares.JWT("", middlewares.AllowApiToken), controllers.ListArticleRevisions)
+		blog.GET("/:id/revisions/diff", middlewares.JWT("", middlewares.AllowApiToken), controllers.DiffArticleRevisions)
+		blog.GET("/:id/revisions/:version", middlewares.JWT("", middlewares.AllowApiToken), controllers.GetArticleRevision)
+		blog.POST("/:id/revisions/:version/restore", middlewares.JWT("blog:write"), controllers.RestoreArticleRevision)
+		blog.PUT("/:id/contributors", middlewares.JWT("blog:write"), controllers.SetArticleContributors)
+		blog.GET("/:id/translations", controllers.GetArticleTranslations)
+		blog.PUT("/:id/translation", middlewares.JWT("blog:write"), controllers.AttachArticleTranslation)
+		blog.DELETE("/:id/translation", middlewares.JWT("blog:write"), controllers.DetachArticleTranslation)
 	}
 	dapp := r.Group("/v1/dapps")
 	{
 		dapp.POST("", middlewares.JWT("dapp:write"), controllers.CreateDapp)
 		dapp.DELETE("/:id", middlewares.JWT("dapp:delete"), controllers.DeleteDapp)
-		dapp.GET("/:id", controllers.GetDapp)
+		dapp.PUT("/:id", middlewares.JWT("dapp:write"), controllers.UpdateDapp)
+		// 审核权限可能限定在某个分类，在控制器中按策略检查
+		dapp.PUT("/:id/status", middlewares.JWT(""), controllers.ReviewDapp)
+		// 匿名用户只能看到已收录的 Dapp
+		dapp.GET("/:id", middlewares.OptionalJWT(middlewares.AllowApiToken), controllers.GetDapp)
 		dapp.GET("/categories", controllers.QueryCategories)
-		dapp.GET("", controllers.QueryDapps)
+		dapp.GET("", middlewares.OptionalJWT(middlewares.AllowApiToken), controllers.QueryDapps)
 	}
 	tutorial := r.Group("/v1/tutorials")
 	{
@@ -60,7 +112,42 @@ func SetupRouter(r *gin.Engine) {
 		tutorial.PUT("/:id", middlewares.JWT("tutorial:write"), controllers.UpdateTutorial)
 		tutorial.GET("/:id", controllers.GetTutorial)
 		tutorial.GET("", controllers.QueryTutorials)
-		tutorial.PUT("/:id/status", middlewares.JWT("tutorial:review"), controllers.UpdateTutorialPublishStatus)
+		// 审核权限可能限定在某个 Dapp 或分类，在控制器中按状态机和策略检查
+		tutorial.PUT("/:id/status", middlewares.JWT("", middlewares.AllowApiToken), controllers.UpdateTutorialPublishStatus)
+		tutorial.GET("/:id/transitions", middlewares.JWT("", middlewares.AllowApiToken), controllers.GetTutorialTransitions)
+		tutorial.PUT("/:id/schedule", middlewares.JWT(""), controllers.ScheduleTutorial)
+		tutorial.DELETE("/:id/schedule", middlewares.JWT(""), controllers.CancelTutorialSchedule)
+		tutorial.GET("/:id/revisions", middlewares.JWT("", middlewares.AllowApiToken), controllers.ListTutorialRevisions)
+		tutorial.GET("/:id/revisions/diff", middlewares.JWT("", middlewares.AllowApiToken), controllers.DiffTutorialRevisions)
+		tutorial.GET("/:id/revisions/:version", middlewares.JWT("", middlewares.AllowApiToken), controllers.GetTutorialRevision)
+		tutorial.POST("/:id/revisions/:version/restore", middlewares.JWT("tutorial:write"), controllers.RestoreTutorialRevision)
+		tutorial.PUT("/:id/contributors", middlewares.JWT("tutorial:write"), controllers.SetTutorialContributors)
+		tutorial.GET("/:id/translations", controllers.GetTutorialTranslations)
+		tutorial.PUT("/:id/translation", middlewares.JWT("tutorial:write"), controllers.AttachTutorialTranslation)
+		tutorial.DELETE("/:id/translation", middlewares.JWT("tutorial:write"), controllers.DetachTutorialTranslation)
+	}
+
+	series := r.Group("v1/series")
+	{
+		series.POST("", middlewares.JWT("tutorial:write"), controllers.CreateSeries)
+		series.DELETE("/:id", middlewares.JWT("tutorial:delete"), controllers.DeleteSeries)
+		series.PUT("/:id", middlewares.JWT("tutorial:write"), controllers.UpdateSeries)
+		// 作者和审核员还能看到未发布的章节
+		series.GET("/:id", middlewares.OptionalJWT(middlewares.AllowApiToken), controllers.GetSeries)
+		series.GET("", controllers.QuerySeries)
+		series.POST("/:id/tutorials", middlewares.JWT("tutorial:write"), controllers.AddSeriesTutorial)
+		series.DELETE("/:id/tutorials/:tutorialId", middlewares.JWT("tutorial:write"), controllers.RemoveSeriesTutorial)
+		series.PUT("/:id/order", middlewares.JWT("tutorial:write"), controllers.ReorderSeries)
+	}
+	// 审核队列，可审核的内容类型和范围在控制器中按策略检查
+	review := r.Group("/v1/review")
+	{
+		review.GET("/queue", middlewares.JWT(""), controllers.GetReviewQueue)
+		review.POST("/:type/:id/claim", middlewares.JWT(""), controllers.ClaimReview)
+		review.PUT("/:type/:id/assignee", middlewares.JWT(""), controllers.AssignReview)
+		review.DELETE("/:type/:id/assignee", middlewares.JWT(""), controllers.UnassignReview)
+		review.GET("/:type/:id/comments", middlewares.JWT(""), controllers.ListReviewComments)
+		review.POST("/:type/:id/comments", middlewares.JWT(""), controllers.CreateReviewComment)
 	}
 	feedback := r.Group("/v1/feedbacks")
 	{
@@ -81,5 +168,42 @@ func SetupRouter(r *gin.Engine) {
 		post.POST("/:id/unfavorite", middlewares.JWT(""), controllers.UnfavoritePost)
 		post.GET("/status", middlewares.JWT(""), controllers.GetPostStatus)
 	}
+	admin := r.Group("/v1/admin")
+	{
+		admin.GET("/permissions", middlewares.JWT("rbac:manage"), controllers.ListPermissions)
+		admin.POST("/permissions", middlewares.JWT("rbac:manage"), controllers.CreatePermission)
+
+		admin.GET("/roles", middlewares.JWT("rbac:manage"), controllers.ListRoles)
+		admin.POST("/roles", middlewares.JWT("rbac:manage"), controllers.CreateRole)
+		admin.GET("/roles/:id", middlewares.JWT("rbac:manage"), controllers.GetRole)
+		admin.PUT("/roles/:id", middlewares.JWT("rbac:manage"), controllers.UpdateRole)
+		admin.DELETE("/roles/:id", middlewares.JWT("rbac:manage"), controllers.DeleteRole)
+		admin.POST("/roles/:id/permissions", middlewares.JWT("rbac:manage"), controllers.AttachRolePermissions)
+		admin.DELETE("/roles/:id/permissions/:permissionId", middlewares.JWT("rbac:manage"), controllers.DetachRolePermission)
+		admin.POST("/roles/:id/groups", middlewares.JWT("rbac:manage"), controllers.AttachRoleGroups)
+		admin.DELETE("/roles/:id/groups/:groupId", middlewares.JWT("rbac:manage"), controllers.DetachRoleGroup)
+		admin.GET("/roles/:id/users", middlewares.JWT("rbac:manage"), controllers.ListRoleUsers)
+
+		admin.GET("/groups", middlewares.JWT("rbac:manage"), controllers.ListPermissionGroups)
+		admin.POST("/groups", middlewares.JWT("rbac:manage"), controllers.CreatePermissionGroup)
+		admin.PUT("/groups/:id", middlewares.JWT("rbac:manage"), controllers.UpdatePermissionGroup)
+		admin.DELETE("/groups/:id", middlewares.JWT("rbac:manage"), controllers.DeletePermissionGroup)
+		admin.POST("/groups/:id/permissions", middlewares.JWT("rbac:manage"), controllers.AttachGroupPermissions)
+		admin.DELETE("/groups/:id/permissions/:permissionId", middlewares.JWT("rbac:manage"), controllers.DetachGroupPermission)
+
+		admin.GET("/moderators", middlewares.JWT("rbac:manage"), controllers.ListModerators)
+		admin.POST("/moderators", middlewares.JWT("rbac:manage"), controllers.CreateModerator)
+		admin.DELETE("/moderators/:id", middlewares.JWT("rbac:manage"), controllers.DeleteModerator)
+
+		admin.GET("/audit-logs", middlewares.JWT("audit:read"), controllers.ListAuditLogs)
+		admin.GET("/audit-logs/export", middlewares.JWT("audit:read"), controllers.ExportAuditLogs)
+
+		admin.GET("/users/:id/roles", middlewares.JWT("rbac:manage"), controllers.ListUserRoles)
+		admin.PUT("/users/:id/roles", middlewares.JWT("rbac:manage"), controllers.SetUserRoles)
+		admin.POST("/users/:id/roles", middlewares.JWT("rbac:manage"), controllers.AddUserRoles)
+		admin.DELETE("/users/:id/roles/:roleId", middlewares.JWT("rbac:manage"), controllers.RemoveUserRole)
+	}
+
 	r.GET("/v1/stats", controllers.StatsOverview)
+	r.GET("/v1/stats/permission-cache", middlewares.JWT("rbac:manage"), controllers.PermissionCacheStats)
 }
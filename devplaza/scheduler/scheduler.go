@@ -22,6 +22,30 @@ func StartScheduler() {
 		log.Fatal("Failed to schedule daily task:", err)
 	}
 
+	// 每 5 分钟检查密钥轮换，并加载其他实例生成的密钥
+	_, err = c.AddFunc("*/5 * * * *", func() {
+		if err := models.RotateSigningKeys(); err != nil {
+			log.Println("Signing key rotation failed:", err)
+		}
+	})
+	if err != nil {
+		log.Fatal("Failed to schedule signing key task:", err)
+	}
+
+	// 每分钟发布到达定时发布时间的博客、教程、活动
+	_, err = c.AddFunc("* * * * *", func() {
+		n, err := models.PublishScheduledContent()
+		if err != nil {
+			log.Println("Scheduled publishing failed:", err)
+		}
+		if n > 0 {
+			log.Printf("Published %d scheduled items", n)
+		}
+	})
+	if err != nil {
+		log.Fatal("Failed to schedule publishing task:", err)
+	}
+
 	c.Start()
 	log.Println("Cron scheduler started.")
 }
@@ -0,0 +1,113 @@
+package utils
+
+import "strings"
+
+// DiffOp 差异行的类型
+type DiffOp string
+
+const (
+	DiffEqual  DiffOp = "equal"
+	DiffInsert DiffOp = "insert"
+	DiffDelete DiffOp = "delete"
+)
+
+// DiffLine 一行差异，OldLine、NewLine 为该行在旧、新文本中的行号（从 1 开始，不存在时为 0）
+type DiffLine struct {
+	Op      DiffOp `json:"op"`
+	Text    string `json:"text"`
+	OldLine int    `json:"old_line,omitempty"`
+	NewLine int    `json:"new_line,omitempty"`
+}
+
+// 中间不同部分的行数乘积超过该值时不再计算最长公共子序列，直接整体替换，避免超大文本占用过多内存
+const maxDiffCells = 4000000
+
+// LineDiff 基于最长公共子序列按行比较两段文本
+func LineDiff(a, b string) []DiffLine {
+	oldLines, newLines := splitLines(a), splitLines(b)
+
+	// 去掉相同的开头和结尾，只对中间部分计算
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	var diff []DiffLine
+	for i := 0; i < prefix; i++ {
+		diff = append(diff, DiffLine{Op: DiffEqual, Text: oldLines[i], OldLine: i + 1, NewLine: i + 1})
+	}
+
+	x := oldLines[prefix : len(oldLines)-suffix]
+	y := newLines[prefix : len(newLines)-suffix]
+	diff = append(diff, lcsDiff(x, y, prefix, prefix)...)
+
+	for i := 0; i < suffix; i++ {
+		oi, ni := len(oldLines)-suffix+i, len(newLines)-suffix+i
+		diff = append(diff, DiffLine{Op: DiffEqual, Text: oldLines[oi], OldLine: oi + 1, NewLine: ni + 1})
+	}
+	return diff
+}
+
+func lcsDiff(x, y []string, oldOffset, newOffset int) []DiffLine {
+	var diff []DiffLine
+	if len(x)*len(y) > maxDiffCells {
+		for i, line := range x {
+			diff = append(diff, DiffLine{Op: DiffDelete, Text: line, OldLine: oldOffset + i + 1})
+		}
+		for j, line := range y {
+			diff = append(diff, DiffLine{Op: DiffInsert, Text: line, NewLine: newOffset + j + 1})
+		}
+		return diff
+	}
+
+	// lcs[i][j] 为 x[i:] 与 y[j:] 的最长公共子序列长度
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			diff = append(diff, DiffLine{Op: DiffEqual, Text: x[i], OldLine: oldOffset + i + 1, NewLine: newOffset + j + 1})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, DiffLine{Op: DiffDelete, Text: x[i], OldLine: oldOffset + i + 1})
+			i++
+		default:
+			diff = append(diff, DiffLine{Op: DiffInsert, Text: y[j], NewLine: newOffset + j + 1})
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		diff = append(diff, DiffLine{Op: DiffDelete, Text: x[i], OldLine: oldOffset + i + 1})
+	}
+	for ; j < len(y); j++ {
+		diff = append(diff, DiffLine{Op: DiffInsert, Text: y[j], NewLine: newOffset + j + 1})
+	}
+	return diff
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+// render 将差异输出为类似 unified diff 的文本，便于比较
+func render(diff []DiffLine) string {
+	var b strings.Builder
+	for _, d := range diff {
+		switch d.Op {
+		case DiffEqual:
+			b.WriteString(" ")
+		case DiffInsert:
+			b.WriteString("+")
+		case DiffDelete:
+			b.WriteString("-")
+		}
+		b.WriteString(d.Text)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func TestLineDiff(t *testing.T) {
+	cases := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"identical", "a\nb\n", "a\nb\n", " a\n b\n"},
+		{"both empty", "", "", ""},
+		{"insert into empty", "", "a\nb", "+a\n+b\n"},
+		{"delete all", "a\nb", "", "-a\n-b\n"},
+		{"change middle", "a\nb\nc", "a\nx\nc", " a\n-b\n+x\n c\n"},
+		{"insert line", "a\nc", "a\nb\nc", " a\n+b\n c\n"},
+		{"crlf", "a\r\nb\r\n", "a\nb\n", " a\n b\n"},
+		{"move", "a\nb\nc\nd", "b\nc\na\nd", "-a\n b\n c\n+a\n d\n"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := render(LineDiff(tc.a, tc.b)); got != tc.want {
+				t.Fatalf("LineDiff() =\n%s\nwant\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLineDiffLineNumbers(t *testing.T) {
+	diff := LineDiff("a\nb\nc", "a\nx\nc")
+	want := []DiffLine{
+		{Op: DiffEqual, Text: "a", OldLine: 1, NewLine: 1},
+		{Op: DiffDelete, Text: "b", OldLine: 2},
+		{Op: DiffInsert, Text: "x", NewLine: 2},
+		{Op: DiffEqual, Text: "c", OldLine: 3, NewLine: 3},
+	}
+	if len(diff) != len(want) {
+		t.Fatalf("LineDiff() = %+v, want %+v", diff, want)
+	}
+	for i := range want {
+		if diff[i] != want[i] {
+			t.Fatalf("line %d = %+v, want %+v", i, diff[i], want[i])
+		}
+	}
+}
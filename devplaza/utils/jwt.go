@@ -1,16 +1,22 @@
 package utils
 
 import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"errors"
+	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/spf13/viper"
 )
 
-// JWT 密钥
-var jwtSecret = viper.GetString("jwt.secret")
-
 // 结构体定义 JWT 负载
 type Claims struct {
 	Uid         uint     `json:"uid"`
@@ -18,35 +24,236 @@ type Claims struct {
 	Avatar      string   `json:"avatar"`
 	Username    string   `json:"username"`
 	Github      string   `json:"github"`
+	Roles       []string `json:"roles"`
 	Permissions []string `json:"permissions"`
+	Sid         string   `json:"sid"`               // 刷新令牌族 ID，吊销后该族签发的访问令牌全部失效
+	Mfa         bool     `json:"mfa,omitempty"`     // 本次登录是否通过了两步验证
+	Purpose     string   `json:"purpose,omitempty"` // 非空表示专用令牌（如两步验证挑战），不能用于访问接口
 	jwt.RegisteredClaims
 }
 
-// 生成 JWT 令牌
-func GenerateToken(uid uint, email, avatar, username, github string, permissions []string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour * 7)
-	claims := Claims{
-		Uid:         uid,
-		Email:       email,
-		Avatar:      avatar,
-		Username:    username,
-		Github:      github,
-		Permissions: permissions,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+// SigningKey JWT 签名密钥，kid 写入令牌头部用于选择验证公钥
+type SigningKey struct {
+	Kid         string
+	Alg         string // RS256 或 EdDSA
+	PrivateKey  crypto.Signer
+	ActivatedAt time.Time // 开始用于签发的时间，之前只用于验证
+}
+
+func (k *SigningKey) method() (jwt.SigningMethod, error) {
+	switch k.Alg {
+	case jwt.SigningMethodRS256.Alg():
+		return jwt.SigningMethodRS256, nil
+	case jwt.SigningMethodEdDSA.Alg():
+		return jwt.SigningMethodEdDSA, nil
+	}
+	return nil, fmt.Errorf("unsupported signing algorithm: %s", k.Alg)
+}
+
+// 当前可用的密钥（包括已停止签发但仍在宽限期内的旧密钥）
+var (
+	keysMu      sync.RWMutex
+	signingKeys []*SigningKey
+)
+
+// SetSigningKeys 替换内存中的密钥集合
+func SetSigningKeys(keys []*SigningKey) {
+	sorted := append([]*SigningKey(nil), keys...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ActivatedAt.After(sorted[j].ActivatedAt)
+	})
+
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	signingKeys = sorted
+}
+
+// currentSigningKey 已激活的最新密钥
+func currentSigningKey() (*SigningKey, error) {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+
+	now := time.Now()
+	for _, k := range signingKeys {
+		if !k.ActivatedAt.After(now) {
+			return k, nil
+		}
+	}
+	return nil, errors.New("no active signing key")
+}
+
+func findSigningKey(kid string) (*SigningKey, bool) {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+
+	for _, k := range signingKeys {
+		if k.Kid == kid {
+			return k, true
+		}
+	}
+	return nil, false
+}
+
+// GenerateSigningKey 生成新的签名密钥
+func GenerateSigningKey(alg string, activatedAt time.Time) (*SigningKey, error) {
+	kid, err := GenerateRandomToken(8)
+	if err != nil {
+		return nil, err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(jwtSecret)) // 生成 Token
+	var priv crypto.Signer
+	switch alg {
+	case jwt.SigningMethodRS256.Alg():
+		priv, err = rsa.GenerateKey(rand.Reader, 2048)
+	case jwt.SigningMethodEdDSA.Alg():
+		_, priv, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &SigningKey{Kid: kid, Alg: alg, PrivateKey: priv, ActivatedAt: activatedAt}, nil
+}
+
+// MarshalPrivateKey 私钥编码为 PKCS#8 PEM
+func MarshalPrivateKey(key crypto.Signer) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
+
+// ParsePrivateKey 解析 PKCS#8 PEM 私钥
+func ParsePrivateKey(data string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("invalid pem")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported private key")
+	}
+	return signer, nil
+}
+
+// PublicJWKS 导出全部公钥，供其他服务验证令牌
+func PublicJWKS() JWKSet {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+
+	set := JWKSet{Keys: []JWK{}}
+	for _, k := range signingKeys {
+		jwk, err := NewJWK(k.Kid, k.Alg, k.PrivateKey.Public())
+		if err != nil {
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// AccessTokenTTL 访问令牌有效期
+func AccessTokenTTL() time.Duration {
+	if ttl := viper.GetDuration("jwt.accessTtl"); ttl > 0 {
+		return ttl
+	}
+	return 30 * time.Minute
+}
+
+// RefreshTokenTTL 刷新令牌有效期
+func RefreshTokenTTL() time.Duration {
+	if ttl := viper.GetDuration("jwt.refreshTtl"); ttl > 0 {
+		return ttl
+	}
+	return 30 * 24 * time.Hour
+}
+
+// mfaTokenPurpose 两步验证挑战令牌，只能用于完成登录
+const mfaTokenPurpose = "mfa"
+
+// 生成 JWT 访问令牌
+func GenerateToken(claims Claims) (string, error) {
+	return signToken(claims, AccessTokenTTL())
+}
+
+// GenerateMfaToken 第一步登录成功后签发的挑战令牌，5 分钟内需提交验证码
+func GenerateMfaToken(uid uint) (string, error) {
+	return signToken(Claims{Uid: uid, Purpose: mfaTokenPurpose}, 5*time.Minute)
+}
+
+// ParseMfaToken 解析挑战令牌，返回的 ID（jti）用于保证令牌只能使用一次
+func ParseMfaToken(tokenString string) (*Claims, error) {
+	claims, err := ParseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != mfaTokenPurpose {
+		return nil, errors.New("invalid token purpose")
+	}
+	return claims, nil
+}
+
+func signToken(claims Claims, ttl time.Duration) (string, error) {
+	key, err := currentSigningKey()
+	if err != nil {
+		return "", err
+	}
+	method, err := key.method()
+	if err != nil {
+		return "", err
+	}
+
+	jti, err := GenerateRandomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        jti,
+		Issuer:    viper.GetString("jwt.issuer"),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(now),
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = key.Kid
+	return token.SignedString(key.PrivateKey) // 生成 Token
 }
 
 // 解析 JWT 令牌
 func ParseToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(jwtSecret), nil
-	})
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			// 迁移期间兼容旧的 HS256 令牌，所有旧令牌过期后删除 jwt.secret 即可
+			secret := viper.GetString("jwt.secret")
+			if secret == "" || token.Method != jwt.SigningMethodHS256 {
+				return nil, errors.New("missing kid")
+			}
+			return []byte(secret), nil
+		}
+
+		key, ok := findSigningKey(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown kid: %s", kid)
+		}
+		if token.Method.Alg() != key.Alg {
+			return nil, errors.New("unexpected signing method")
+		}
+		return key.PrivateKey.Public(), nil
+	}, jwt.WithValidMethods([]string{
+		jwt.SigningMethodRS256.Alg(),
+		jwt.SigningMethodEdDSA.Alg(),
+		jwt.SigningMethodHS256.Alg(),
+	}))
 
 	if err != nil || !token.Valid {
 		return nil, err
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"testing"
+	"time"
+)
+
+func TestGenerateAndParseToken(t *testing.T) {
+	for _, alg := range []string{"RS256", "EdDSA"} {
+		key, err := GenerateSigningKey(alg, time.Now().Add(-time.Minute))
+		if err != nil {
+			t.Fatalf("%s: generate key: %v", alg, err)
+		}
+		SetSigningKeys([]*SigningKey{key})
+
+		token, err := GenerateToken(Claims{Uid: 7, Sid: "family"})
+		if err != nil {
+			t.Fatalf("%s: generate token: %v", alg, err)
+		}
+		claims, err := ParseToken(token)
+		if err != nil {
+			t.Fatalf("%s: parse token: %v", alg, err)
+		}
+		if claims.Uid != 7 || claims.Sid != "family" {
+			t.Errorf("%s: unexpected claims %+v", alg, claims)
+		}
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	old, _ := GenerateSigningKey("RS256", time.Now().Add(-time.Hour))
+	next, _ := GenerateSigningKey("RS256", time.Now().Add(time.Hour))
+	SetSigningKeys([]*SigningKey{next, old})
+
+	// 新密钥未到启用时间，仍用旧密钥签发
+	key, err := currentSigningKey()
+	if err != nil || key.Kid != old.Kid {
+		t.Fatalf("expected old key to sign, got %v %v", key, err)
+	}
+	token, _ := GenerateToken(Claims{Uid: 1})
+
+	// 新密钥启用后，旧密钥签发的令牌仍可验证
+	next.ActivatedAt = time.Now().Add(-time.Second)
+	SetSigningKeys([]*SigningKey{old, next})
+	if key, _ := currentSigningKey(); key.Kid != next.Kid {
+		t.Fatalf("expected new key to sign, got %s", key.Kid)
+	}
+	if _, err := ParseToken(token); err != nil {
+		t.Fatalf("token signed by retired key rejected: %v", err)
+	}
+
+	// 旧密钥移除后不再接受
+	SetSigningKeys([]*SigningKey{next})
+	if _, err := ParseToken(token); err == nil {
+		t.Fatal("token signed by removed key accepted")
+	}
+}
+
+func TestPublicJWKS(t *testing.T) {
+	key, _ := GenerateSigningKey("RS256", time.Now())
+	SetSigningKeys([]*SigningKey{key})
+
+	set := PublicJWKS()
+	jwk, ok := set.Find(key.Kid)
+	if !ok {
+		t.Fatal("kid not published")
+	}
+	pub, err := jwk.PublicKey()
+	if err != nil {
+		t.Fatalf("parse published key: %v", err)
+	}
+	if !pub.(*rsa.PublicKey).Equal(key.PrivateKey.Public()) {
+		t.Error("published key mismatch")
+	}
+}
+
+func TestPrivateKeyPEM(t *testing.T) {
+	key, _ := GenerateSigningKey("EdDSA", time.Now())
+	data, err := MarshalPrivateKey(key.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParsePrivateKey(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Public().(ed25519.PublicKey).Equal(key.PrivateKey.Public()) {
+		t.Error("parsed key mismatch")
+	}
+}
+
+func TestMfaToken(t *testing.T) {
+	key, _ := GenerateSigningKey("EdDSA", time.Now().Add(-time.Minute))
+	SetSigningKeys([]*SigningKey{key})
+
+	token, err := GenerateMfaToken(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ParseMfaToken(token)
+	if err != nil || claims.Uid != 42 || claims.ID == "" {
+		t.Fatalf("ParseMfaToken() = %+v, %v", claims, err)
+	}
+
+	access, _ := GenerateToken(Claims{Uid: 42, Sid: "family"})
+	if _, err := ParseMfaToken(access); err == nil {
+		t.Error("access token accepted as mfa token")
+	}
+}
@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const siwePreamble = " wants you to sign in with your Ethereum account:"
+
+// SiweMessage EIP-4361 登录消息
+type SiweMessage struct {
+	Domain         string
+	Address        string
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        int64
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// ParseSiweMessage 按 EIP-4361 格式解析登录消息
+func ParseSiweMessage(message string) (*SiweMessage, error) {
+	lines := strings.Split(strings.ReplaceAll(message, "\r\n", "\n"), "\n")
+	if len(lines) < 2 || !strings.HasSuffix(lines[0], siwePreamble) {
+		return nil, errors.New("invalid siwe message header")
+	}
+
+	var msg SiweMessage
+	msg.Domain = strings.TrimSuffix(lines[0], siwePreamble)
+	if msg.Domain == "" {
+		return nil, errors.New("missing domain")
+	}
+
+	if !common.IsHexAddress(lines[1]) {
+		return nil, errors.New("invalid address")
+	}
+	msg.Address = common.HexToAddress(lines[1]).Hex()
+
+	i := 2
+	// 空行之后是可选的 statement，再接一个空行
+	if i < len(lines) && lines[i] == "" {
+		i++
+		if i < len(lines) && !strings.HasPrefix(lines[i], "URI: ") {
+			msg.Statement = lines[i]
+			i++
+			if i < len(lines) && lines[i] == "" {
+				i++
+			}
+		}
+	}
+
+	inResources := false
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if inResources {
+			if strings.HasPrefix(line, "- ") {
+				msg.Resources = append(msg.Resources, strings.TrimPrefix(line, "- "))
+				continue
+			}
+			inResources = false
+		}
+		if line == "" {
+			continue
+		}
+		if line == "Resources:" {
+			inResources = true
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			return nil, fmt.Errorf("invalid line: %q", line)
+		}
+
+		var err error
+		switch key {
+		case "URI":
+			msg.URI = value
+		case "Version":
+			msg.Version = value
+		case "Chain ID":
+			msg.ChainID, err = strconv.ParseInt(value, 10, 64)
+		case "Nonce":
+			msg.Nonce = value
+		case "Issued At":
+			msg.IssuedAt, err = time.Parse(time.RFC3339, value)
+		case "Expiration Time":
+			var t time.Time
+			t, err = time.Parse(time.RFC3339, value)
+			msg.ExpirationTime = &t
+		case "Not Before":
+			var t time.Time
+			t, err = time.Parse(time.RFC3339, value)
+			msg.NotBefore = &t
+		case "Request ID":
+			msg.RequestID = value
+		default:
+			return nil, fmt.Errorf("unknown field: %s", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", key, err)
+		}
+	}
+
+	if msg.URI == "" || msg.Version == "" || msg.ChainID == 0 || msg.Nonce == "" || msg.IssuedAt.IsZero() {
+		return nil, errors.New("missing required field")
+	}
+	if msg.Version != "1" {
+		return nil, errors.New("unsupported version")
+	}
+
+	return &msg, nil
+}
+
+// Validate 检查消息的有效期
+func (m *SiweMessage) Validate(now time.Time) error {
+	if m.ExpirationTime != nil && now.After(*m.ExpirationTime) {
+		return errors.New("message expired")
+	}
+	if m.NotBefore != nil && now.Before(*m.NotBefore) {
+		return errors.New("message not yet valid")
+	}
+	return nil
+}
+
+// RecoverPersonalSignAddress 从 personal_sign（EIP-191）签名中恢复签名者地址
+func RecoverPersonalSignAddress(message, signature string) (string, error) {
+	sig, err := hexutil.Decode(signature)
+	if err != nil {
+		return "", err
+	}
+	if len(sig) != crypto.SignatureLength {
+		return "", errors.New("invalid signature length")
+	}
+
+	// 钱包返回的 v 为 27/28
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	hash := crypto.Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(message), message)))
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return "", err
+	}
+	return crypto.PubkeyToAddress(*pub).Hex(), nil
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const testSiweMessage = `devplaza.xyz wants you to sign in with your Ethereum account:
+0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2
+
+Sign in to DevPlaza
+
+URI: https://devplaza.xyz/login
+Version: 1
+Chain ID: 10143
+Nonce: 32891756
+Issued At: 2025-09-01T12:00:00Z
+Expiration Time: 2025-09-01T12:10:00Z
+Resources:
+- https://devplaza.xyz/terms`
+
+func TestParseSiweMessage(t *testing.T) {
+	msg, err := ParseSiweMessage(testSiweMessage)
+	if err != nil {
+		t.Fatalf("ParseSiweMessage() error = %v", err)
+	}
+
+	if msg.Domain != "devplaza.xyz" {
+		t.Errorf("Domain = %v", msg.Domain)
+	}
+	if msg.Address != "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2" {
+		t.Errorf("Address = %v", msg.Address)
+	}
+	if msg.Statement != "Sign in to DevPlaza" {
+		t.Errorf("Statement = %v", msg.Statement)
+	}
+	if msg.ChainID != 10143 || msg.Nonce != "32891756" {
+		t.Errorf("ChainID = %v, Nonce = %v", msg.ChainID, msg.Nonce)
+	}
+	if len(msg.Resources) != 1 {
+		t.Errorf("Resources = %v", msg.Resources)
+	}
+
+	if err := msg.Validate(time.Date(2025, 9, 1, 12, 5, 0, 0, time.UTC)); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err := msg.Validate(time.Date(2025, 9, 1, 12, 11, 0, 0, time.UTC)); err == nil {
+		t.Errorf("Validate() expected expiration error")
+	}
+}
+
+func TestParseSiweMessageInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+	}{
+		{"empty", ""},
+		{"bad header", "devplaza.xyz wants you to log in:\n0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"},
+		{"bad address", "devplaza.xyz wants you to sign in with your Ethereum account:\n0x1234"},
+		{"missing nonce", "devplaza.xyz wants you to sign in with your Ethereum account:\n0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2\n\nURI: https://devplaza.xyz\nVersion: 1\nChain ID: 1\nIssued At: 2025-09-01T12:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseSiweMessage(tt.message); err == nil {
+				t.Errorf("ParseSiweMessage() expected error")
+			}
+		})
+	}
+}
+
+func TestRecoverPersonalSignAddress(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash := crypto.Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(testSiweMessage), testSiweMessage)))
+	sig, err := crypto.Sign(hash, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+
+	got, err := RecoverPersonalSignAddress(testSiweMessage, hexutil.Encode(sig))
+	if err != nil {
+		t.Fatalf("RecoverPersonalSignAddress() error = %v", err)
+	}
+	if want := crypto.PubkeyToAddress(key.PublicKey).Hex(); got != want {
+		t.Errorf("RecoverPersonalSignAddress() = %v, want %v", got, want)
+	}
+}
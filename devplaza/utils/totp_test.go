@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录 B 的 SHA1 测试向量
+func TestHotpRFC6238(t *testing.T) {
+	key := []byte("12345678901234567890")
+	cases := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, tc := range cases {
+		if got := hotp(key, uint64(tc.unix/totpPeriod), 8); got != tc.want {
+			t.Errorf("hotp(%d) = %s, want %s", tc.unix, got, tc.want)
+		}
+	}
+}
+
+func TestValidateTotp(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111111, 0)
+
+	code, err := TotpCode(secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != "050471" {
+		t.Fatalf("TotpCode() = %s, want 050471", code)
+	}
+
+	step, ok := ValidateTotp(secret, code, now)
+	if !ok || step != 1111111111/totpPeriod {
+		t.Fatalf("ValidateTotp() = %d, %v", step, ok)
+	}
+
+	// 允许一个时间窗口的偏差
+	if _, ok := ValidateTotp(secret, code, now.Add(totpPeriod*time.Second)); !ok {
+		t.Error("code rejected within skew")
+	}
+	if _, ok := ValidateTotp(secret, code, now.Add(3*totpPeriod*time.Second)); ok {
+		t.Error("code accepted outside skew")
+	}
+	if _, ok := ValidateTotp(secret, "000000", now); ok {
+		t.Error("wrong code accepted")
+	}
+}
+
+func TestGenerateTotpSecret(t *testing.T) {
+	secret, err := GenerateTotpSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secret) != 32 {
+		t.Errorf("secret length = %d, want 32", len(secret))
+	}
+
+	uri := TotpURI("DevPlaza", "alice@example.com", secret)
+	if !strings.HasPrefix(uri, "otpauth://totp/DevPlaza:alice@example.com?") || !strings.Contains(uri, "secret="+secret) {
+		t.Errorf("unexpected uri %s", uri)
+	}
+}
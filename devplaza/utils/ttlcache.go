@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type ttlEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// TTLCache 带过期时间的进程内缓存，并统计命中率
+type TTLCache[K comparable, V any] struct {
+	mu        sync.RWMutex
+	ttl       time.Duration
+	items     map[K]ttlEntry[V]
+	nextSweep time.Time // 下次清理过期条目的时间，每个 ttl 最多清理一次
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+}
+
+type CacheStats struct {
+	Hits    uint64  `json:"hits"`
+	Misses  uint64  `json:"misses"`
+	Size    int     `json:"size"`
+	HitRate float64 `json:"hit_rate"`
+}
+
+func NewTTLCache[K comparable, V any](ttl time.Duration) *TTLCache[K, V] {
+	return &TTLCache[K, V]{
+		ttl:       ttl,
+		items:     make(map[K]ttlEntry[V]),
+		nextSweep: time.Now().Add(ttl),
+	}
+}
+
+func (c *TTLCache[K, V]) Get(key K) (V, bool) {
+	c.mu.RLock()
+	entry, ok := c.items[key]
+	c.mu.RUnlock()
+
+	if ok && time.Now().After(entry.expiresAt) {
+		// 过期条目在读取时删除，期间被重新写入的不删
+		c.mu.Lock()
+		if e, exists := c.items[key]; exists && time.Now().After(e.expiresAt) {
+			delete(c.items, key)
+		}
+		c.mu.Unlock()
+		ok = false
+	}
+	if !ok {
+		c.misses.Add(1)
+		var zero V
+		return zero, false
+	}
+
+	c.hits.Add(1)
+	return entry.value, true
+}
+
+func (c *TTLCache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	c.items[key] = ttlEntry[V]{value: value, expiresAt: now.Add(c.ttl)}
+
+	// 不再读取的条目不会在 Get 中删除，每个 ttl 统一清理一次，避免 map 无限增长
+	if now.Before(c.nextSweep) {
+		return
+	}
+	c.nextSweep = now.Add(c.ttl)
+	for k, e := range c.items {
+		if now.After(e.expiresAt) {
+			delete(c.items, k)
+		}
+	}
+}
+
+func (c *TTLCache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+}
+
+func (c *TTLCache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = make(map[K]ttlEntry[V])
+}
+
+func (c *TTLCache[K, V]) Stats() CacheStats {
+	c.mu.RLock()
+	size := len(c.items)
+	c.mu.RUnlock()
+
+	stats := CacheStats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+		Size:   size,
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
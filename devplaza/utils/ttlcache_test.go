@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTLCache(t *testing.T) {
+	cache := NewTTLCache[uint, []string](50 * time.Millisecond)
+
+	if _, ok := cache.Get(1); ok {
+		t.Fatalf("Get() on empty cache should miss")
+	}
+
+	cache.Set(1, []string{"blog:write"})
+	if v, ok := cache.Get(1); !ok || len(v) != 1 {
+		t.Fatalf("Get() = %v, %v, want hit", v, ok)
+	}
+
+	cache.Delete(1)
+	if _, ok := cache.Get(1); ok {
+		t.Fatalf("Get() after Delete() should miss")
+	}
+
+	cache.Set(2, []string{"event:write"})
+	time.Sleep(60 * time.Millisecond)
+	if _, ok := cache.Get(2); ok {
+		t.Fatalf("Get() after ttl should miss")
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 3 {
+		t.Errorf("Stats() = %+v, want 1 hit and 3 misses", stats)
+	}
+	if stats.HitRate != 0.25 {
+		t.Errorf("HitRate = %v, want 0.25", stats.HitRate)
+	}
+}
+
+func TestTTLCacheEvictsExpired(t *testing.T) {
+	cache := NewTTLCache[uint, int](20 * time.Millisecond)
+
+	cache.Set(1, 1)
+	cache.Set(2, 2)
+	time.Sleep(30 * time.Millisecond)
+
+	// 读取时删除过期条目
+	cache.Get(1)
+	if size := cache.Stats().Size; size != 1 {
+		t.Fatalf("Size after Get() = %d, want 1", size)
+	}
+
+	// 超过 ttl 后的写入清理其他过期条目
+	cache.Set(3, 3)
+	if size := cache.Stats().Size; size != 1 {
+		t.Errorf("Size after Set() = %d, want 1", size)
+	}
+}
@@ -0,0 +1,169 @@
+package workflow
+
+import "errors"
+
+var (
+	ErrInvalidStatus     = errors.New("invalid status")
+	ErrInvalidTransition = errors.New("transition not allowed")
+	ErrCommentRequired   = errors.New("comment required")
+)
+
+// Status 内容发布状态，1、2 与旧的 publish_status 保持一致
+type Status uint
+
+const (
+	PendingReview    Status = 1 // 待审核
+	Published        Status = 2 // 已发布
+	Draft            Status = 3 // 草稿
+	ChangesRequested Status = 4 // 审核未通过，需要修改
+	Unpublished      Status = 5 // 已下架
+	Archived         Status = 6 // 已归档
+	Scheduled        Status = 7 // 审核通过，等待定时发布
+	Rejected         Status = 8 // 已拒绝，仅用于 Dapp
+)
+
+var statusNames = map[Status]string{
+	PendingReview:    "pending_review",
+	Published:        "published",
+	Draft:            "draft",
+	ChangesRequested: "changes_requested",
+	Unpublished:      "unpublished",
+	Archived:         "archived",
+	Scheduled:        "scheduled",
+	Rejected:         "rejected",
+}
+
+func (s Status) Valid() bool {
+	_, ok := statusNames[s]
+	return ok
+}
+
+func (s Status) String() string {
+	if name, ok := statusNames[s]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Actor 发起状态变更的角色
+type Actor int
+
+const (
+	Author   Actor = iota // 作者
+	Reviewer              // 审核员
+	System                // 定时任务
+)
+
+type transition struct {
+	from, to Status
+	actor    Actor
+	comment  bool // 是否必须填写原因
+}
+
+var transitions = []transition{
+	// 作者
+	{Draft, PendingReview, Author, false},            // 提交审核
+	{PendingReview, Draft, Author, false},            // 撤回
+	{ChangesRequested, PendingReview, Author, false}, // 修改后重新提交
+	{ChangesRequested, Draft, Author, false},
+	{Published, Unpublished, Author, false}, // 作者自行下架
+	{Unpublished, PendingReview, Author, false},
+	{Draft, Archived, Author, false},
+	{ChangesRequested, Archived, Author, false},
+	{Unpublished, Archived, Author, false},
+	{Archived, Draft, Author, false},
+	{Scheduled, PendingReview, Author, false}, // 取消定时发布
+
+	// 审核员
+	{PendingReview, Published, Reviewer, false},       // 通过
+	{PendingReview, ChangesRequested, Reviewer, true}, // 驳回，必须说明原因
+	{Published, PendingReview, Reviewer, false},       // 退回待审核
+	{Published, Unpublished, Reviewer, true},          // 下架，必须说明原因
+	{Unpublished, Published, Reviewer, false},
+	{Published, Archived, Reviewer, false},
+	{Unpublished, Archived, Reviewer, false},
+	{PendingReview, Scheduled, Reviewer, false}, // 通过并定时发布
+	{Unpublished, Scheduled, Reviewer, false},
+	{Scheduled, Published, Reviewer, false}, // 立即发布
+	{Scheduled, PendingReview, Reviewer, false},
+	{Scheduled, ChangesRequested, Reviewer, true},
+
+	// 定时任务
+	{Scheduled, Published, System, false},
+}
+
+// dappTransitions Dapp 收录流程只有待审核、已通过（Published）、已拒绝三种状态
+var dappTransitions = []transition{
+	{PendingReview, Published, Reviewer, false},
+	{PendingReview, Rejected, Reviewer, true}, // 拒绝，必须说明原因
+	{Published, Rejected, Reviewer, true},     // 撤下已收录的 Dapp
+	{Rejected, Published, Reviewer, false},
+	{Rejected, PendingReview, Author, false},  // 修改后重新提交
+	{Published, PendingReview, Author, false}, // 修改已收录的 Dapp 后重新审核
+}
+
+// Check 检查 actors 中任一角色能否将博客、教程、活动的状态从 from 变更为 to
+func Check(from, to Status, comment string, actors ...Actor) error {
+	return check(transitions, from, to, comment, actors)
+}
+
+// CheckDapp 检查 actors 中任一角色能否将 Dapp 的状态从 from 变更为 to
+func CheckDapp(from, to Status, comment string, actors ...Actor) error {
+	return check(dappTransitions, from, to, comment, actors)
+}
+
+func check(table []transition, from, to Status, comment string, actors []Actor) error {
+	if !from.Valid() || !to.Valid() {
+		return ErrInvalidStatus
+	}
+	for _, t := range table {
+		if t.from != from || t.to != to || !hasActor(actors, t.actor) {
+			continue
+		}
+		if t.comment && comment == "" {
+			return ErrCommentRequired
+		}
+		return nil
+	}
+	return ErrInvalidTransition
+}
+
+// Next 当前状态下 actors 可以变更到的状态
+func Next(from Status, actors ...Actor) []Status {
+	var next []Status
+	seen := make(map[Status]bool)
+	for _, t := range transitions {
+		if t.from == from && hasActor(actors, t.actor) && !seen[t.to] {
+			seen[t.to] = true
+			next = append(next, t.to)
+		}
+	}
+	return next
+}
+
+// AfterEdit 作者编辑内容后的状态：已发布、已下架、等待定时发布的内容重新进入审核，被驳回的内容视为重新提交
+func AfterEdit(s Status) Status {
+	switch s {
+	case Published, Unpublished, ChangesRequested, Scheduled:
+		return PendingReview
+	}
+	return s
+}
+
+// AfterDappEdit 提交者修改 Dapp 后的状态：已收录、已拒绝的 Dapp 重新进入审核
+func AfterDappEdit(s Status) Status {
+	switch s {
+	case Published, Rejected:
+		return PendingReview
+	}
+	return s
+}
+
+func hasActor(actors []Actor, a Actor) bool {
+	for _, x := range actors {
+		if x == a {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package workflow
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	cases := []struct {
+		name    string
+		from    Status
+		to      Status
+		comment string
+		actors  []Actor
+		want    error
+	}{
+		{"author submit draft", Draft, PendingReview, "", []Actor{Author}, nil},
+		{"author withdraw", PendingReview, Draft, "", []Actor{Author}, nil},
+		{"author resubmit", ChangesRequested, PendingReview, "", []Actor{Author}, nil},
+		{"author cannot publish", PendingReview, Published, "", []Actor{Author}, ErrInvalidTransition},
+		{"author unpublish", Published, Unpublished, "", []Actor{Author}, nil},
+		{"author restore archived", Archived, Draft, "", []Actor{Author}, nil},
+
+		{"reviewer approve", PendingReview, Published, "", []Actor{Reviewer}, nil},
+		{"reviewer reject without comment", PendingReview, ChangesRequested, "", []Actor{Reviewer}, ErrCommentRequired},
+		{"reviewer reject", PendingReview, ChangesRequested, "missing sources", []Actor{Reviewer}, nil},
+		{"reviewer takedown without comment", Published, Unpublished, "", []Actor{Reviewer}, ErrCommentRequired},
+		{"reviewer cannot publish draft", Draft, Published, "", []Actor{Reviewer}, ErrInvalidTransition},
+		{"reviewer send back", Published, PendingReview, "", []Actor{Reviewer}, nil},
+
+		{"reviewer schedule", PendingReview, Scheduled, "", []Actor{Reviewer}, nil},
+		{"author cannot schedule", PendingReview, Scheduled, "", []Actor{Author}, ErrInvalidTransition},
+		{"author cancel schedule", Scheduled, PendingReview, "", []Actor{Author}, nil},
+		{"system publish scheduled", Scheduled, Published, "", []Actor{System}, nil},
+		{"system cannot publish pending", PendingReview, Published, "", []Actor{System}, ErrInvalidTransition},
+
+		// 同时是作者和审核员时，作者下架不需要原因
+		{"author and reviewer unpublish", Published, Unpublished, "", []Actor{Author, Reviewer}, nil},
+		{"no actor", PendingReview, Published, "", nil, ErrInvalidTransition},
+		{"same status", Published, Published, "", []Actor{Author, Reviewer}, ErrInvalidTransition},
+		{"content can not be rejected", PendingReview, Rejected, "spam", []Actor{Reviewer}, ErrInvalidTransition},
+		{"unknown status", PendingReview, Status(9), "", []Actor{Reviewer}, ErrInvalidStatus},
+		{"zero status", Status(0), PendingReview, "", []Actor{Author}, ErrInvalidStatus},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Check(tc.from, tc.to, tc.comment, tc.actors...); !errors.Is(got, tc.want) {
+				t.Fatalf("Check(%s, %s) = %v, want %v", tc.from, tc.to, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCheckDapp(t *testing.T) {
+	cases := []struct {
+		name    string
+		from    Status
+		to      Status
+		comment string
+		actors  []Actor
+		want    error
+	}{
+		{"reviewer approve", PendingReview, Published, "", []Actor{Reviewer}, nil},
+		{"reviewer reject without comment", PendingReview, Rejected, "", []Actor{Reviewer}, ErrCommentRequired},
+		{"reviewer reject", PendingReview, Rejected, "site is down", []Actor{Reviewer}, nil},
+		{"reviewer take down", Published, Rejected, "scam", []Actor{Reviewer}, nil},
+		{"owner cannot approve", PendingReview, Published, "", []Actor{Author}, ErrInvalidTransition},
+		{"owner resubmit", Rejected, PendingReview, "", []Actor{Author}, nil},
+		{"owner edit published", Published, PendingReview, "", []Actor{Author}, nil},
+		{"owner cannot restore taken down", Rejected, Published, "", []Actor{Author}, ErrInvalidTransition},
+		{"no scheduling for dapps", PendingReview, Scheduled, "", []Actor{Reviewer}, ErrInvalidTransition},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := CheckDapp(tc.from, tc.to, tc.comment, tc.actors...); !errors.Is(got, tc.want) {
+				t.Fatalf("CheckDapp(%s, %s) = %v, want %v", tc.from, tc.to, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	got := Next(PendingReview, Reviewer)
+	want := []Status{Published, ChangesRequested, Scheduled}
+	if len(got) != len(want) {
+		t.Fatalf("Next = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Next = %v, want %v", got, want)
+		}
+	}
+
+	if got := Next(Archived, Reviewer); len(got) != 0 {
+		t.Fatalf("reviewer should not restore archived content, got %v", got)
+	}
+}
+
+func TestAfterEdit(t *testing.T) {
+	cases := map[Status]Status{
+		Draft:            Draft,
+		PendingReview:    PendingReview,
+		ChangesRequested: PendingReview,
+		Published:        PendingReview,
+		Unpublished:      PendingReview,
+		Archived:         Archived,
+		Scheduled:        PendingReview,
+	}
+	for from, want := range cases {
+		if got := AfterEdit(from); got != want {
+			t.Errorf("AfterEdit(%s) = %s, want %s", from, got, want)
+		}
+	}
+}
+
+func TestAfterDappEdit(t *testing.T) {
+	cases := map[Status]Status{
+		PendingReview: PendingReview,
+		Published:     PendingReview,
+		Rejected:      PendingReview,
+	}
+	for from, want := range cases {
+		if got := AfterDappEdit(from); got != want {
+			t.Errorf("AfterDappEdit(%s) = %s, want %s", from, got, want)
+		}
+		if from != want {
+			if err := CheckDapp(from, want, "", Author); err != nil {
+				t.Errorf("CheckDapp(%s, %s) = %v after edit", from, want, err)
+			}
+		}
+	}
+}